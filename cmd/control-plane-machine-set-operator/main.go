@@ -92,7 +92,9 @@ func main() { //nolint:funlen
 		os.Exit(1)
 	}
 
-	if err := (&cpmswebhook.ControlPlaneMachineSetWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+	if err := (&cpmswebhook.ControlPlaneMachineSetWebhook{
+		Namespace: "openshift-machine-api",
+	}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ControlPlaneMachineSet")
 		os.Exit(1)
 	}
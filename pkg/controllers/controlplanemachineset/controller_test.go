@@ -392,6 +392,15 @@ var _ = Describe("ensureOwnerRefrences", func() {
 		)
 	})
 
+	It("should build machines owned by the ControlPlaneMachineSet", func() {
+		machine := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("owned-machine-").WithControllerOwner(cpms).Build()
+
+		Expect(machine.GetOwnerReferences()).To(ConsistOf(expectedOwnerReference))
+
+		Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+		Eventually(komega.Object(machine)).Should(HaveField("ObjectMeta.OwnerReferences", ConsistOf(expectedOwnerReference)))
+	})
+
 	Context("when the machines do not have existing owner references", func() {
 		BeforeEach(func() {
 			err := reconciler.ensureOwnerReferences(ctx, logger.Logger(), cpms, machineInfos)
@@ -966,5 +975,11 @@ var _ = Describe("isControlPlaneMachineSetDegraded", func() {
 			cpmsBuilder.WithConditions([]metav1.Condition{degradedConditionBuilder.WithStatus(metav1.ConditionTrue).Build()}).Build(),
 			true,
 		),
+		Entry("with a CPMS with only an available condition with status true",
+			cpmsBuilder.WithConditions([]metav1.Condition{
+				resourcebuilder.AvailableCondition(metav1.ConditionTrue, reasonAllReplicasAvailable, ""),
+			}).Build(),
+			false,
+		),
 	)
 })
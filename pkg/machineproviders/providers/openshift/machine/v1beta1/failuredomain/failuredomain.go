@@ -17,9 +17,14 @@ limitations under the License.
 package failuredomain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
@@ -39,6 +44,18 @@ var (
 	// errMissingFailureDomain is an error used when failure domain platform is set
 	// but the failure domain list is nil.
 	errMissingFailureDomain = errors.New("missing failure domain configuration")
+
+	// errUnsupportedMachineType is an error used when the failure domains are
+	// requested from a template with an unknown machine type.
+	errUnsupportedMachineType = errors.New("unsupported machine type")
+
+	// errMismatchedPlatformType is an error used when a failure domain is compared
+	// with the failure domain of a different platform type.
+	errMismatchedPlatformType = errors.New("mismatched platform type")
+
+	// errMissingAvailabilityZone is an error used when an AWS failure domain
+	// specifies a subnet but no availability zone.
+	errMissingAvailabilityZone = errors.New("missing availability zone")
 )
 
 // FailureDomain is an interface that allows external code to interact with
@@ -50,6 +67,11 @@ type FailureDomain interface {
 	// Type returns the platform type of the failure domain.
 	Type() configv1.PlatformType
 
+	// Platform is an alias of Type.
+	// This allows callers handling mixed lists of failure domains to check
+	// that they all belong to the same platform.
+	Platform() configv1.PlatformType
+
 	// AWS returns the AWSFailureDomain if the platform type is AWS.
 	AWS() machinev1.AWSFailureDomain
 
@@ -64,6 +86,23 @@ type FailureDomain interface {
 
 	// Equal compares the underlying failure domain.
 	Equal(other FailureDomain) bool
+
+	// Hash returns a stable key for the failure domain.
+	// Failure domains that are Equal have the same hash, which allows
+	// failure domains to be used as map keys.
+	Hash() string
+
+	// Matches checks whether the failure domain corresponds to the failure domain
+	// configured within the provider config, for example the provider config of a Machine.
+	Matches(Extractor) (bool, error)
+}
+
+// Extractor is implemented by types that hold failure domain information, such as a ProviderConfig.
+// This allows failure domains to be compared with provider configs without importing the
+// providerconfig package, which depends on this package.
+type Extractor interface {
+	// ExtractFailureDomain is used to extract a failure domain.
+	ExtractFailureDomain() FailureDomain
 }
 
 // failureDomain holds an implementation of the FailureDomain interface.
@@ -97,6 +136,11 @@ func (f failureDomain) Type() configv1.PlatformType {
 	return f.platformType
 }
 
+// Platform is an alias of Type, it returns the platform type of the failure domain.
+func (f failureDomain) Platform() configv1.PlatformType {
+	return f.Type()
+}
+
 // AWS returns the AWSFailureDomain if the platform type is AWS.
 func (f failureDomain) AWS() machinev1.AWSFailureDomain {
 	return f.aws
@@ -118,6 +162,7 @@ func (f failureDomain) OpenStack() machinev1.OpenStackFailureDomain {
 }
 
 // Equal compares the underlying failure domain.
+// AWS subnet filters are compared irrespective of the order of the filters and their values.
 func (f failureDomain) Equal(other FailureDomain) bool {
 	if f.platformType != other.Type() {
 		return false
@@ -125,7 +170,7 @@ func (f failureDomain) Equal(other FailureDomain) bool {
 
 	switch f.platformType {
 	case configv1.AWSPlatformType:
-		return reflect.DeepEqual(f.AWS(), other.AWS())
+		return reflect.DeepEqual(normalizeAWSFailureDomain(f.AWS()), normalizeAWSFailureDomain(other.AWS()))
 	case configv1.AzurePlatformType:
 		return f.azure == other.Azure()
 	case configv1.GCPPlatformType:
@@ -137,6 +182,152 @@ func (f failureDomain) Equal(other FailureDomain) bool {
 	return false
 }
 
+// Matches checks whether the failure domain corresponds to the failure domain extracted from the provider config.
+// On AWS, the availability zone must match, and the subnet must match when the failure domain specifies
+// a subnet. Failure domains that do not specify a subnet match any subnet within the availability zone.
+// On other platforms, the failure domains must be equal.
+func (f failureDomain) Matches(pc Extractor) (bool, error) {
+	other := pc.ExtractFailureDomain()
+
+	if f.platformType != other.Type() {
+		return false, fmt.Errorf("%w: failure domain has platform %s, provider config has platform %s", errMismatchedPlatformType, f.platformType, other.Type())
+	}
+
+	if f.platformType != configv1.AWSPlatformType {
+		return f.Equal(other), nil
+	}
+
+	if f.aws.Placement.AvailabilityZone != other.AWS().Placement.AvailabilityZone {
+		return false, nil
+	}
+
+	if f.aws.Subnet == nil {
+		return true, nil
+	}
+
+	return f.Equal(other), nil
+}
+
+// Hash returns a stable key for the failure domain.
+// The key is derived from the platform type and the JSON encoding of the
+// underlying failure domain, so that pointer and slice fields are compared
+// by value rather than by reference.
+func (f failureDomain) Hash() string {
+	var fd interface{}
+
+	switch f.platformType {
+	case configv1.AWSPlatformType:
+		fd = normalizeAWSFailureDomain(f.aws)
+	case configv1.AzurePlatformType:
+		fd = f.azure
+	case configv1.GCPPlatformType:
+		fd = f.gcp
+	case configv1.OpenStackPlatformType:
+		fd = f.openStack
+	}
+
+	data, err := json.Marshal(fd)
+	if err != nil {
+		// The failure domain types are plain data and should always marshal,
+		// fall back to the string representation if they do not.
+		data = []byte(f.String())
+	}
+
+	sum := sha256.Sum256(append([]byte(f.platformType+":"), data...))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeAWSFailureDomain returns a copy of the AWSFailureDomain with the subnet filters,
+// and the values within each filter, sorted so that semantically equivalent failure domains
+// are identical.
+func normalizeAWSFailureDomain(fd machinev1.AWSFailureDomain) machinev1.AWSFailureDomain {
+	if fd.Subnet == nil || fd.Subnet.Filters == nil {
+		return fd
+	}
+
+	normalized := *fd.DeepCopy()
+	filters := *normalized.Subnet.Filters
+
+	for i := range filters {
+		sort.Strings(filters[i].Values)
+	}
+
+	sort.SliceStable(filters, func(i, j int) bool {
+		if filters[i].Name != filters[j].Name {
+			return filters[i].Name < filters[j].Name
+		}
+
+		return strings.Join(filters[i].Values, ",") < strings.Join(filters[j].Values, ",")
+	})
+
+	return normalized
+}
+
+// SetsEqual compares two lists of failure domains as unordered sets.
+// It returns true when every failure domain in each list is also present in the other list.
+// Individual failure domains are compared with FailureDomain.Equal.
+func SetsEqual(a, b []FailureDomain) bool {
+	return len(Difference(a, b)) == 0 && len(Difference(b, a)) == 0
+}
+
+// Difference returns the distinct failure domains from list1 that are not present in list2,
+// in the order in which they first appear in list1.
+// Failure domains are compared using their Hash, which is equivalent to comparing them with Equal.
+func Difference(list1, list2 []FailureDomain) []FailureDomain {
+	seen := map[string]struct{}{}
+
+	for _, fd := range list2 {
+		seen[fd.Hash()] = struct{}{}
+	}
+
+	difference := []FailureDomain{}
+
+	for _, fd := range list1 {
+		if _, ok := seen[fd.Hash()]; ok {
+			continue
+		}
+
+		seen[fd.Hash()] = struct{}{}
+		difference = append(difference, fd)
+	}
+
+	return difference
+}
+
+// Distinct returns the failure domains from the list with any duplicates removed,
+// in the order in which they first appear.
+func Distinct(failureDomains []FailureDomain) []FailureDomain {
+	return Difference(failureDomains, nil)
+}
+
+// NextForIndex returns the failure domain that the replica with the given index should use.
+// The failure domains are sorted by their string representation and then assigned to indexes
+// in a round robin fashion, so that the result is stable regardless of the input order.
+// This matches the base failure domain mapping used when distributing control plane machines.
+// When no failure domains are provided, or the index is negative, nil is returned.
+func NextForIndex(index int32, domains []FailureDomain) FailureDomain {
+	if len(domains) == 0 || index < 0 {
+		return nil
+	}
+
+	sorted := sortByString(domains)
+
+	return sorted[int(index)%len(sorted)]
+}
+
+// sortByString returns a copy of the failure domains sorted by their string representation.
+func sortByString(domains []FailureDomain) []FailureDomain {
+	sorted := make([]FailureDomain, len(domains))
+	copy(sorted, domains)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+
+	return sorted
+}
+
 // NewFailureDomains creates a set of FailureDomains representing the input failure
 // domains held within the ControlPlaneMachineSet.
 func NewFailureDomains(failureDomains machinev1.FailureDomains) ([]FailureDomain, error) {
@@ -157,6 +348,21 @@ func NewFailureDomains(failureDomains machinev1.FailureDomains) ([]FailureDomain
 	}
 }
 
+// NewFromTemplate creates a set of FailureDomains representing the failure domains
+// declared within the ControlPlaneMachineSet template.
+// A template without failure domains results in an empty set of FailureDomains.
+func NewFromTemplate(tmpl machinev1.ControlPlaneMachineSetTemplate) ([]FailureDomain, error) {
+	if tmpl.MachineType != machinev1.OpenShiftMachineV1Beta1MachineType {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedMachineType, tmpl.MachineType)
+	}
+
+	if tmpl.OpenShiftMachineV1Beta1Machine == nil {
+		return nil, nil
+	}
+
+	return NewFailureDomains(tmpl.OpenShiftMachineV1Beta1Machine.FailureDomains)
+}
+
 // newAWSFailureDomains constructs a slice of AWS FailureDomain from machinev1.FailureDomains.
 func newAWSFailureDomains(failureDomains machinev1.FailureDomains) ([]FailureDomain, error) {
 	foundFailureDomains := []FailureDomain{}
@@ -213,6 +419,22 @@ func newOpenStackFailureDomains(failureDomains machinev1.FailureDomains) ([]Fail
 	return foundFailureDomains, nil
 }
 
+// ValidateAWS checks that the AWS failure domain is consistent.
+// A subnet only exists within a single availability zone, so a failure domain that
+// specifies a subnet must also specify the availability zone the subnet belongs to.
+func ValidateAWS(fd FailureDomain) error {
+	if fd.Type() != configv1.AWSPlatformType {
+		return fmt.Errorf("%w: %s", errUnsupportedPlatformType, fd.Type())
+	}
+
+	aws := fd.AWS()
+	if aws.Subnet != nil && aws.Placement.AvailabilityZone == "" {
+		return fmt.Errorf("%w: failure domain %s specifies a subnet and must specify the availability zone of the subnet", errMissingAvailabilityZone, fd.String())
+	}
+
+	return nil
+}
+
 // NewAWSFailureDomain creates an AWS failure domain from the machinev1.AWSFailureDomain.
 // Note this is exported to allow other packages to construct individual failure domains
 // in tests.
@@ -22,9 +22,21 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// staticExtractor is an Extractor that returns a fixed failure domain.
+type staticExtractor struct {
+	failureDomain FailureDomain
+}
+
+// ExtractFailureDomain returns the fixed failure domain.
+func (s staticExtractor) ExtractFailureDomain() FailureDomain {
+	return s.failureDomain
+}
+
 var _ = Describe("FailureDomains", func() {
 	Context("NewFailureDomains", func() {
 		Context("with no failure domains configuration", func() {
@@ -238,6 +250,432 @@ var _ = Describe("FailureDomains", func() {
 		})
 	})
 
+	Context("NewFromTemplate", func() {
+		It("should construct a list of failure domains from an AWS template", func() {
+			tmpl := resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).
+				WithFailureDomainsBuilder(resourcebuilder.AWSFailureDomains()).
+				BuildTemplate()
+
+			failureDomains, err := NewFromTemplate(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(failureDomains).To(ConsistOf(
+				HaveField("String()", "AWSFailureDomain{AvailabilityZone:us-east-1a, Subnet:{Type:id, Value:subenet-us-east-1a}}"),
+				HaveField("String()", "AWSFailureDomain{AvailabilityZone:us-east-1b, Subnet:{Type:id, Value:subenet-us-east-1b}}"),
+				HaveField("String()", "AWSFailureDomain{AvailabilityZone:us-east-1c, Subnet:{Type:id, Value:subenet-us-east-1c}}"),
+			))
+		})
+
+		It("should return a nil list for a template without failure domains", func() {
+			tmpl := resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).
+				BuildTemplate()
+
+			failureDomains, err := NewFromTemplate(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(failureDomains).To(BeNil())
+		})
+
+		It("should return an error for an unsupported failure domains platform", func() {
+			tmpl := resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).
+				WithPlatformType(configv1.BareMetalPlatformType).
+				BuildTemplate()
+
+			_, err := NewFromTemplate(tmpl)
+			Expect(err).To(MatchError(errUnsupportedPlatformType))
+			Expect(err).To(MatchError("unsupported platform type: BareMetal"))
+		})
+
+		It("should return an error for an unsupported machine type", func() {
+			_, err := NewFromTemplate(machinev1.ControlPlaneMachineSetTemplate{MachineType: "unknown"})
+			Expect(err).To(MatchError("unsupported machine type: unknown"))
+		})
+	})
+
+	Context("Platform", func() {
+		type platformTableInput struct {
+			failureDomain    FailureDomain
+			expectedPlatform configv1.PlatformType
+		}
+
+		DescribeTable("should return the platform of the failure domain", func(in platformTableInput) {
+			Expect(in.failureDomain.Platform()).To(Equal(in.expectedPlatform))
+			Expect(in.failureDomain.Platform()).To(Equal(in.failureDomain.Type()))
+		},
+			Entry("with an AWS failure domain", platformTableInput{
+				failureDomain:    NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build()),
+				expectedPlatform: configv1.AWSPlatformType,
+			}),
+			Entry("with an Azure failure domain", platformTableInput{
+				failureDomain:    NewAzureFailureDomain(resourcebuilder.AzureFailureDomain().WithZone("1").Build()),
+				expectedPlatform: configv1.AzurePlatformType,
+			}),
+			Entry("with a GCP failure domain", platformTableInput{
+				failureDomain:    NewGCPFailureDomain(resourcebuilder.GCPFailureDomain().WithZone("us-central1-a").Build()),
+				expectedPlatform: configv1.GCPPlatformType,
+			}),
+			Entry("with an OpenStack failure domain", platformTableInput{
+				failureDomain:    NewOpenStackFailureDomain(resourcebuilder.OpenStackFailureDomain().WithAvailabilityZone("zone-a").Build()),
+				expectedPlatform: configv1.OpenStackPlatformType,
+			}),
+		)
+
+		DescribeTable("should construct failure domains for the platform of the generic builder", func(platform configv1.PlatformType) {
+			failureDomains := resourcebuilder.FailureDomains(platform).BuildFailureDomains()
+			Expect(failureDomains.Platform).To(Equal(platform))
+
+			fds, err := NewFailureDomains(failureDomains)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fds).ToNot(BeEmpty())
+
+			for _, fd := range fds {
+				Expect(fd.Platform()).To(Equal(platform))
+			}
+		},
+			Entry("with AWS", configv1.AWSPlatformType),
+			Entry("with Azure", configv1.AzurePlatformType),
+			Entry("with GCP", configv1.GCPPlatformType),
+		)
+	})
+
+	Context("SetsEqual", func() {
+		type equalListsTableInput struct {
+			a             []FailureDomain
+			b             []FailureDomain
+			expectedEqual bool
+		}
+
+		usEast1a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build())
+		usEast1b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build())
+		usEast1c := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").Build())
+
+		DescribeTable("should compare the failure domains as sets", func(in equalListsTableInput) {
+			Expect(SetsEqual(in.a, in.b)).To(Equal(in.expectedEqual))
+			Expect(SetsEqual(in.b, in.a)).To(Equal(in.expectedEqual), "Equality should be symmetric")
+		},
+			Entry("with two empty lists", equalListsTableInput{
+				a:             []FailureDomain{},
+				b:             []FailureDomain{},
+				expectedEqual: true,
+			}),
+			Entry("with equal lists in the same order", equalListsTableInput{
+				a:             []FailureDomain{usEast1a, usEast1b, usEast1c},
+				b:             []FailureDomain{usEast1a, usEast1b, usEast1c},
+				expectedEqual: true,
+			}),
+			Entry("with equal lists in a different order", equalListsTableInput{
+				a:             []FailureDomain{usEast1a, usEast1b, usEast1c},
+				b:             []FailureDomain{usEast1c, usEast1a, usEast1b},
+				expectedEqual: true,
+			}),
+			Entry("with lists differing by one element", equalListsTableInput{
+				a:             []FailureDomain{usEast1a, usEast1b, usEast1c},
+				b:             []FailureDomain{usEast1a, usEast1b},
+				expectedEqual: false,
+			}),
+			Entry("with lists of the same length differing by one element", equalListsTableInput{
+				a:             []FailureDomain{usEast1a, usEast1b},
+				b:             []FailureDomain{usEast1a, usEast1c},
+				expectedEqual: false,
+			}),
+		)
+	})
+
+	Context("Difference", func() {
+		usEast1a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build())
+		usEast1b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build())
+		usEast1c := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").Build())
+
+		type differenceTableInput struct {
+			list1    []FailureDomain
+			list2    []FailureDomain
+			expected []FailureDomain
+		}
+
+		DescribeTable("should return the failure domains missing from the second list", func(in differenceTableInput) {
+			Expect(Difference(in.list1, in.list2)).To(Equal(in.expected))
+		},
+			Entry("with two empty lists", differenceTableInput{
+				list1:    []FailureDomain{},
+				list2:    []FailureDomain{},
+				expected: []FailureDomain{},
+			}),
+			Entry("with equal lists in a different order", differenceTableInput{
+				list1:    []FailureDomain{usEast1a, usEast1b, usEast1c},
+				list2:    []FailureDomain{usEast1c, usEast1a, usEast1b},
+				expected: []FailureDomain{},
+			}),
+			Entry("with a failure domain missing from the second list", differenceTableInput{
+				list1:    []FailureDomain{usEast1c, usEast1a, usEast1b},
+				list2:    []FailureDomain{usEast1a},
+				expected: []FailureDomain{usEast1c, usEast1b},
+			}),
+			Entry("with duplicate failure domains in the first list", differenceTableInput{
+				list1:    []FailureDomain{usEast1b, usEast1c, usEast1b},
+				list2:    []FailureDomain{usEast1a},
+				expected: []FailureDomain{usEast1b, usEast1c},
+			}),
+		)
+
+		It("should remove duplicates with Distinct", func() {
+			duplicate := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build())
+
+			Expect(Distinct([]FailureDomain{usEast1a, usEast1b, duplicate, usEast1c, usEast1b})).To(Equal([]FailureDomain{usEast1a, usEast1b, usEast1c}))
+		})
+	})
+
+	Context("NextForIndex", func() {
+		usEast1a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build())
+		usEast1b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build())
+		usEast1c := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").Build())
+
+		type nextForIndexTableInput struct {
+			index    int32
+			domains  []FailureDomain
+			expected FailureDomain
+		}
+
+		DescribeTable("should return the failure domain for the index", func(in nextForIndexTableInput) {
+			if in.expected == nil {
+				Expect(NextForIndex(in.index, in.domains)).To(BeNil())
+				return
+			}
+
+			Expect(NextForIndex(in.index, in.domains)).To(Equal(in.expected))
+		},
+			Entry("with no failure domains", nextForIndexTableInput{
+				index:    0,
+				domains:  []FailureDomain{},
+				expected: nil,
+			}),
+			Entry("with a negative index", nextForIndexTableInput{
+				index:    -1,
+				domains:  []FailureDomain{usEast1a, usEast1b, usEast1c},
+				expected: nil,
+			}),
+			Entry("with index 0 and unordered failure domains", nextForIndexTableInput{
+				index:    0,
+				domains:  []FailureDomain{usEast1c, usEast1a, usEast1b},
+				expected: usEast1a,
+			}),
+			Entry("with index 2 and unordered failure domains", nextForIndexTableInput{
+				index:    2,
+				domains:  []FailureDomain{usEast1b, usEast1c, usEast1a},
+				expected: usEast1c,
+			}),
+		)
+
+		It("should wrap around when there are more indexes than failure domains", func() {
+			domains := []FailureDomain{usEast1b, usEast1c, usEast1a}
+
+			out := []FailureDomain{}
+			for i := int32(0); i < 5; i++ {
+				out = append(out, NextForIndex(i, domains))
+			}
+
+			Expect(out).To(Equal([]FailureDomain{usEast1a, usEast1b, usEast1c, usEast1a, usEast1b}))
+		})
+	})
+
+	Context("Hash", func() {
+		filterSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{{
+				Name:   "tag:Name",
+				Values: []string{"aws-subnet-12345678"},
+			}},
+		}
+
+		It("should return the same hash for equal failure domains", func() {
+			a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build())
+			b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(*filterSubnet.DeepCopy()).Build())
+
+			Expect(a.Equal(b)).To(BeTrue())
+			Expect(a.Hash()).To(Equal(b.Hash()))
+		})
+
+		It("should return different hashes for different failure domains", func() {
+			a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build())
+			b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build())
+
+			Expect(a.Hash()).ToNot(Equal(b.Hash()))
+		})
+
+		It("should return different hashes for failure domains on different platforms", func() {
+			a := NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-a"})
+			b := NewAzureFailureDomain(machinev1.AzureFailureDomain{Zone: "us-central1-a"})
+
+			Expect(a.Hash()).ToNot(Equal(b.Hash()))
+		})
+	})
+
+	Context("NewFromRawExtension", func() {
+		It("should extract the availability zone and subnet from an AWS provider spec", func() {
+			raw := resourcebuilder.AWSProviderSpec().
+				WithAvailabilityZone("us-east-1b").
+				WithSubnet(machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{{
+						Name:   "tag:Name",
+						Values: []string{"aws-subnet-us-east-1b"},
+					}},
+				}).
+				BuildRawExtension()
+
+			expected := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().
+				WithAvailabilityZone("us-east-1b").
+				WithSubnet(machinev1.AWSResourceReference{
+					Type: machinev1.AWSFiltersReferenceType,
+					Filters: &[]machinev1.AWSResourceFilter{{
+						Name:   "tag:Name",
+						Values: []string{"aws-subnet-us-east-1b"},
+					}},
+				}).
+				Build(),
+			)
+
+			fd, err := NewFromRawExtension(*raw, configv1.AWSPlatformType)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fd.Equal(expected)).To(BeTrue(), "expected %s, got %s", expected, fd)
+		})
+
+		It("should extract an ID type subnet from an AWS provider spec", func() {
+			subnetID := "subnet-12345678"
+
+			raw := resourcebuilder.AWSProviderSpec().
+				WithSubnet(machinev1beta1.AWSResourceReference{ID: &subnetID}).
+				BuildRawExtension()
+
+			fd, err := NewFromRawExtension(*raw, configv1.AWSPlatformType)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fd.AWS().Subnet).To(Equal(&machinev1.AWSResourceReference{
+				Type: machinev1.AWSIDReferenceType,
+				ID:   &subnetID,
+			}))
+		})
+
+		It("should extract the zone from a GCP provider spec", func() {
+			raw := resourcebuilder.GCPProviderSpec().WithZone("us-central1-b").BuildRawExtension()
+
+			fd, err := NewFromRawExtension(*raw, configv1.GCPPlatformType)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fd.Equal(NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-b"}))).To(BeTrue())
+		})
+
+		It("should return an error when the provider spec cannot be decoded", func() {
+			_, err := NewFromRawExtension(runtime.RawExtension{Raw: []byte("{")}, configv1.AWSPlatformType)
+			Expect(err).To(MatchError(ContainSubstring("could not unmarshal provider spec")))
+		})
+
+		It("should return an error for an unsupported platform", func() {
+			raw := resourcebuilder.AzureProviderSpec().BuildRawExtension()
+
+			_, err := NewFromRawExtension(*raw, configv1.AzurePlatformType)
+			Expect(err).To(MatchError("unsupported platform type: Azure"))
+		})
+	})
+
+	Context("ValidateAWS", func() {
+		filterSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{{
+				Name:   "tag:Name",
+				Values: []string{"aws-subnet-us-east-1a"},
+			}},
+		}
+
+		It("should accept a failure domain with an availability zone and subnet", func() {
+			fd := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build())
+
+			Expect(ValidateAWS(fd)).To(Succeed())
+		})
+
+		It("should accept a failure domain without an availability zone or subnet", func() {
+			Expect(ValidateAWS(NewAWSFailureDomain(machinev1.AWSFailureDomain{}))).To(Succeed())
+		})
+
+		It("should reject a failure domain with a subnet but no availability zone", func() {
+			fd := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithSubnet(filterSubnet).Build())
+
+			err := ValidateAWS(fd)
+			Expect(err).To(MatchError(errMissingAvailabilityZone))
+			Expect(err).To(MatchError("missing availability zone: failure domain AWSFailureDomain{Subnet:{Type:filters, Value:&[{Name:tag:Name Values:[aws-subnet-us-east-1a]}]}} specifies a subnet and must specify the availability zone of the subnet"))
+		})
+
+		It("should reject a failure domain from another platform", func() {
+			Expect(ValidateAWS(NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-a"}))).To(MatchError("unsupported platform type: GCP"))
+		})
+	})
+
+	Context("Matches", func() {
+		filterSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{{
+				Name:   "tag:Name",
+				Values: []string{"aws-subnet-us-east-1a"},
+			}},
+		}
+
+		otherFilterSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{{
+				Name:   "tag:Name",
+				Values: []string{"aws-subnet-other"},
+			}},
+		}
+
+		type matchesTableInput struct {
+			failureDomain         FailureDomain
+			providerFailureDomain FailureDomain
+			expectedMatch         bool
+			expectedError         string
+		}
+
+		DescribeTable("should compare the failure domain with the provider config", func(in matchesTableInput) {
+			match, err := in.failureDomain.Matches(staticExtractor{failureDomain: in.providerFailureDomain})
+
+			if in.expectedError != "" {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(match).To(Equal(in.expectedMatch))
+		},
+			Entry("with a matching AWS availability zone and subnet", matchesTableInput{
+				failureDomain:         NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build()),
+				providerFailureDomain: NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build()),
+				expectedMatch:         true,
+			}),
+			Entry("with a matching AWS availability zone and no subnet in the failure domain", matchesTableInput{
+				failureDomain:         NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build()),
+				providerFailureDomain: NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build()),
+				expectedMatch:         true,
+			}),
+			Entry("with a different AWS availability zone", matchesTableInput{
+				failureDomain:         NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build()),
+				providerFailureDomain: NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet).Build()),
+				expectedMatch:         false,
+			}),
+			Entry("with a different AWS subnet", matchesTableInput{
+				failureDomain:         NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build()),
+				providerFailureDomain: NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(otherFilterSubnet).Build()),
+				expectedMatch:         false,
+			}),
+			Entry("with a matching GCP zone", matchesTableInput{
+				failureDomain:         NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-a"}),
+				providerFailureDomain: NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-a"}),
+				expectedMatch:         true,
+			}),
+			Entry("with different platforms", matchesTableInput{
+				failureDomain:         NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build()),
+				providerFailureDomain: NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-a"}),
+				expectedMatch:         false,
+				expectedError:         "mismatched platform type: failure domain has platform AWS, provider config has platform GCP",
+			}),
+		)
+	})
+
 	Context("an AWS failure domain", func() {
 		var fd failureDomain
 
@@ -271,6 +709,11 @@ var _ = Describe("FailureDomains", func() {
 				It("returns the subnet for String()", func() {
 					Expect(fd.String()).To(Equal("AWSFailureDomain{Subnet:{Type:arn, Value:subnet-us-east-1a}}"))
 				})
+
+				It("does not default the availability zone", func() {
+					Expect(fd.AWS().Placement.AvailabilityZone).To(BeEmpty())
+					Expect(fd.AWS().Subnet).ToNot(BeNil())
+				})
 			})
 
 			Context("with a filter type subnet", func() {
@@ -479,6 +922,57 @@ var _ = Describe("FailureDomains", func() {
 			})
 		})
 
+		Context("With reordered AWS subnet filters", func() {
+			subnetWithFilters := func(filters ...machinev1.AWSResourceFilter) machinev1.AWSResourceReference {
+				return machinev1.AWSResourceReference{
+					Type:    machinev1.AWSFiltersReferenceType,
+					Filters: &filters,
+				}
+			}
+
+			nameFilter := machinev1.AWSResourceFilter{Name: "tag:Name", Values: []string{"aws-subnet-12345678", "aws-subnet-87654321"}}
+			reorderedNameFilter := machinev1.AWSResourceFilter{Name: "tag:Name", Values: []string{"aws-subnet-87654321", "aws-subnet-12345678"}}
+			vpcFilter := machinev1.AWSResourceFilter{Name: "vpc-id", Values: []string{"vpc-12345678"}}
+
+			It("should treat reordered subnet filters as equal", func() {
+				a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter, vpcFilter)).Build())
+				b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(vpcFilter, nameFilter)).Build())
+
+				Expect(a.Equal(b)).To(BeTrue())
+				Expect(b.Equal(a)).To(BeTrue())
+				Expect(a.Hash()).To(Equal(b.Hash()))
+			})
+
+			It("should treat reordered subnet filter values as equal", func() {
+				a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter)).Build())
+				b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(reorderedNameFilter)).Build())
+
+				Expect(a.Equal(b)).To(BeTrue())
+				Expect(a.Hash()).To(Equal(b.Hash()))
+			})
+
+			It("should not modify the original subnet filters", func() {
+				a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(vpcFilter, reorderedNameFilter)).Build())
+				b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter, vpcFilter)).Build())
+
+				Expect(a.Equal(b)).To(BeTrue())
+				Expect(*a.AWS().Subnet.Filters).To(Equal([]machinev1.AWSResourceFilter{vpcFilter, reorderedNameFilter}))
+			})
+
+			It("should treat different subnet filters as not equal", func() {
+				a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter)).Build())
+				b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter, vpcFilter)).Build())
+
+				Expect(a.Equal(b)).To(BeFalse())
+			})
+
+			It("should compare reordered subnet filters as equal sets", func() {
+				a := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(nameFilter, vpcFilter)).Build())
+				b := NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(subnetWithFilters(vpcFilter, reorderedNameFilter)).Build())
+
+				Expect(SetsEqual([]FailureDomain{a}, []FailureDomain{b})).To(BeTrue())
+			})
+		})
 	})
 
 })
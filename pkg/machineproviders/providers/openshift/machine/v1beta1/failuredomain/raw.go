@@ -0,0 +1,114 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package failuredomain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NewFromRawExtension decodes the raw provider spec for the given platform
+// and returns the failure domain described by it.
+func NewFromRawExtension(raw runtime.RawExtension, platform configv1.PlatformType) (FailureDomain, error) {
+	switch platform {
+	case configv1.AWSPlatformType:
+		return newAWSFailureDomainFromRawExtension(raw)
+	case configv1.GCPPlatformType:
+		return newGCPFailureDomainFromRawExtension(raw)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, platform)
+	}
+}
+
+// newAWSFailureDomainFromRawExtension extracts the availability zone and
+// subnet from a raw AWSMachineProviderConfig.
+func newAWSFailureDomainFromRawExtension(raw runtime.RawExtension) (FailureDomain, error) {
+	providerConfig := machinev1beta1.AWSMachineProviderConfig{}
+	if err := json.Unmarshal(raw.Raw, &providerConfig); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider spec: %w", err)
+	}
+
+	return NewAWSFailureDomain(AWSFailureDomainFromProviderConfig(providerConfig)), nil
+}
+
+// newGCPFailureDomainFromRawExtension extracts the zone from a raw GCPMachineProviderSpec.
+func newGCPFailureDomainFromRawExtension(raw runtime.RawExtension) (FailureDomain, error) {
+	providerSpec := machinev1beta1.GCPMachineProviderSpec{}
+	if err := json.Unmarshal(raw.Raw, &providerSpec); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider spec: %w", err)
+	}
+
+	return NewGCPFailureDomain(GCPFailureDomainFromProviderSpec(providerSpec)), nil
+}
+
+// AWSFailureDomainFromProviderConfig returns the AWSFailureDomain described by the availability zone
+// and subnet of the AWSMachineProviderConfig.
+func AWSFailureDomainFromProviderConfig(providerConfig machinev1beta1.AWSMachineProviderConfig) machinev1.AWSFailureDomain {
+	return machinev1.AWSFailureDomain{
+		Placement: machinev1.AWSFailureDomainPlacement{
+			AvailabilityZone: providerConfig.Placement.AvailabilityZone,
+		},
+		Subnet: ConvertAWSResourceReferenceV1Beta1ToV1(providerConfig.Subnet),
+	}
+}
+
+// GCPFailureDomainFromProviderSpec returns the GCPFailureDomain described by the zone of the GCPMachineProviderSpec.
+func GCPFailureDomainFromProviderSpec(providerSpec machinev1beta1.GCPMachineProviderSpec) machinev1.GCPFailureDomain {
+	return machinev1.GCPFailureDomain{
+		Zone: providerSpec.Zone,
+	}
+}
+
+// ConvertAWSResourceReferenceV1Beta1ToV1 creates a machinev1.AWSResourceReference from a machinev1beta1.AWSResourceReference.
+func ConvertAWSResourceReferenceV1Beta1ToV1(referenceV1Beta1 machinev1beta1.AWSResourceReference) *machinev1.AWSResourceReference {
+	referenceV1 := &machinev1.AWSResourceReference{}
+
+	if referenceV1Beta1.ID != nil {
+		referenceV1.Type = machinev1.AWSIDReferenceType
+		referenceV1.ID = referenceV1Beta1.ID
+
+		return referenceV1
+	}
+
+	if referenceV1Beta1.Filters != nil {
+		referenceV1.Type = machinev1.AWSFiltersReferenceType
+
+		referenceV1.Filters = &[]machinev1.AWSResourceFilter{}
+		for _, filter := range referenceV1Beta1.Filters {
+			*referenceV1.Filters = append(*referenceV1.Filters, machinev1.AWSResourceFilter{
+				Name:   filter.Name,
+				Values: filter.Values,
+			})
+		}
+
+		return referenceV1
+	}
+
+	if referenceV1Beta1.ARN != nil {
+		referenceV1.Type = machinev1.AWSARNReferenceType
+		referenceV1.ARN = referenceV1Beta1.ARN
+
+		return referenceV1
+	}
+
+	return nil
+}
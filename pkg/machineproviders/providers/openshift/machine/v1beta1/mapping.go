@@ -20,12 +20,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,6 +43,10 @@ var (
 	// errNoFailureDomains is used to indicate that no failure domain mapping is required in the
 	// provider because no failure domains are configured on the ControlPlaneMachineSet.
 	errNoFailureDomains = errors.New("no failure domains configured")
+
+	// errInvalidReplicas is used to inform users that failure domains cannot be assigned to a
+	// number of replicas that is less than one.
+	errInvalidReplicas = errors.New("replicas must be greater than zero")
 )
 
 // mapMachineIndexesToFailureDomains creates a mapping of the given failure domains into an index that can be used
@@ -63,42 +73,220 @@ func mapMachineIndexesToFailureDomains(ctx context.Context, logger logr.Logger,
 	return outputMapping, nil
 }
 
+// AssignFailureDomains maps each index, up to the number of replicas, to one of the failure domains provided.
+// Existing Machines keep the failure domain in which they currently reside, provided that failure domain is still
+// present and keeping it does not unbalance the spread of Machines across the failure domains. This minimises churn
+// when the failure domains change. The remaining indexes are then filled in a round robin fashion, starting from
+// the base failure domain mapping.
+// The index of an existing Machine is the integer suffix of its name. When multiple Machines share an index, the
+// newest Machine takes precedence.
+func AssignFailureDomains(existing []machinev1beta1.Machine, domains []failuredomain.FailureDomain, replicas int) (map[int32]failuredomain.FailureDomain, error) {
+	if len(domains) == 0 {
+		return nil, errNoFailureDomains
+	}
+
+	if replicas < 1 {
+		return nil, fmt.Errorf("%w: %d", errInvalidReplicas, replicas)
+	}
+
+	current, err := currentFailureDomains(logr.Discard(), existing, int32(replicas))
+	if err != nil {
+		return nil, fmt.Errorf("could not determine the current failure domains of the existing machines: %w", err)
+	}
+
+	return reconcileMappings(logr.Discard(), baseFailureDomainMapping(int32(replicas), domains), current), nil
+}
+
+// currentFailureDomains returns, by index, the failure domain in which each Machine resides.
+// Machines without an index, or with an index outside of the replicas, are not included.
+// When multiple Machines share an index, the newest Machine takes precedence.
+func currentFailureDomains(logger logr.Logger, machines []machinev1beta1.Machine, replicas int32) (map[int32]failuredomain.FailureDomain, error) {
+	// Sort the oldest Machines first so that newer Machines take precedence for an index.
+	sortedMachines := make([]machinev1beta1.Machine, len(machines))
+	copy(sortedMachines, machines)
+
+	sort.SliceStable(sortedMachines, func(i, j int) bool {
+		return sortedMachines[i].CreationTimestamp.Before(&sortedMachines[j].CreationTimestamp)
+	})
+
+	out := make(map[int32]failuredomain.FailureDomain)
+	machineNames := make(map[int32]string)
+
+	for _, machine := range sortedMachines {
+		index, ok := machineIndexFromName(machine.Name)
+		if !ok || index >= replicas {
+			logger.V(4).Info("Ignoring machine in failure domain mapping with unexpected name", "machine", machine.Name)
+
+			continue
+		}
+
+		pc, err := providerconfig.NewProviderConfigFromMachineSpec(machine.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("could not get provider config for machine %s: %w", machine.Name, err)
+		}
+
+		fd := pc.ExtractFailureDomain()
+
+		if existing, ok := out[index]; ok && !existing.Equal(fd) {
+			logger.V(4).Info("Conflicting failure domains found for the same index, relying on the newer machine",
+				"oldMachine", machineNames[index],
+				"oldFailureDomain", existing.String(),
+				"newerMachine", machine.Name,
+				"newerFailureDomain", fd.String(),
+			)
+		}
+
+		out[index] = fd
+		machineNames[index] = machine.Name
+	}
+
+	return out, nil
+}
+
+// machineIndexFromName returns the index of a Machine based on the integer suffix of its name.
+// For example, the Machine cluster-master-2 has the index 2.
+func machineIndexFromName(name string) (int32, bool) {
+	suffix := name[strings.LastIndex(name, "-")+1:]
+
+	index, err := strconv.ParseInt(suffix, 10, 32)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+
+	return int32(index), true
+}
+
 // createBaseFailureDomainMapping is used to create the basic failure domain mapping based on the number of failure
 // domains provided and the number of replicas within the ControlPlaneMachineSet.
 // To ensure consistency, we expect the function to create a stable output no matter the order of the input failure
 // domains.
 func createBaseFailureDomainMapping(cpms *machinev1.ControlPlaneMachineSet, failureDomains []failuredomain.FailureDomain) (map[int32]failuredomain.FailureDomain, error) {
+	if cpms.Spec.Replicas == nil {
+		return nil, errReplicasRequired
+	}
+
+	return baseFailureDomainMapping(*cpms.Spec.Replicas, failureDomains), nil
+}
+
+// baseFailureDomainMapping maps each index, up to the number of replicas, to the failure domains
+// in a round robin fashion, irrespective of the order of the failure domains provided.
+func baseFailureDomainMapping(replicas int32, failureDomains []failuredomain.FailureDomain) map[int32]failuredomain.FailureDomain {
 	out := make(map[int32]failuredomain.FailureDomain)
 
-	// TODO: Check replicas is set, then sort the failure domains alphabetically, and use modulo arithmetic to set up the
-	// output map.
+	for i := int32(0); i < replicas; i++ {
+		out[i] = failuredomain.NextForIndex(i, failureDomains)
+	}
 
-	return out, nil
+	return out
 }
 
 // createMachineMapping inspects the state of the Machines on the cluster, selected by the ControlPlaneMachineSet, and
 // creates a mapping of their indexes (if available) to their failure domain to allow the mapping to be customised
 // to the state of the cluster.
 func createMachineMapping(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) (map[int32]failuredomain.FailureDomain, error) {
-	out := make(map[int32]failuredomain.FailureDomain)
+	if cpms.Spec.Replicas == nil {
+		return nil, errReplicasRequired
+	}
 
-	// TODO: Use the CPMS selector to fetch Machines from the API. Extract the providerconfig from these to then get the
-	// failure domain information out of the Machine. Inspect the Machine name, if it ends with an integer, take that as
-	// its index and set the failure domain in the output map. Newest machines should take precedence when inferring the
-	// failure domain if multiple machines match an index.
-	// If the Machine name does not end in an integer (or it is out of bounds of the replicas expected), then ignore the
-	// Machine.
-	// To test "Newest machines should take precedence", the logic after fetching the machines should be in a helper
-	// function that can be unit tested separately.
+	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert label selector to selector: %w", err)
+	}
 
-	return out, nil
+	machineList := machinev1beta1.MachineList{}
+	if err := cl.List(ctx, &machineList, client.InNamespace(cpms.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("error querying api for machines: %w", err)
+	}
+
+	return currentFailureDomains(logger, machineList.Items, *cpms.Spec.Replicas)
 }
 
 // reconcileMappings takes a base mapping and a machines mapping and reconciles the differences. If any machine failure
 // domain has an identical failure domain in the base mapping, the mapping from the Machine should take precedence.
 // When overwriting a mapping, the mapping in place must be swapped to avoid losing information.
+// The number of indexes each failure domain holds in the base mapping is kept, so that the Machines remain balanced
+// across the failure domains.
 func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomain.FailureDomain) map[int32]failuredomain.FailureDomain {
+	replicas := int32(len(base))
+	domains := make([]failuredomain.FailureDomain, 0, len(base))
+	capacity := make(map[string]int)
+
+	for i := int32(0); i < replicas; i++ {
+		domains = append(domains, base[i])
+		capacity[base[i].Hash()]++
+	}
+
+	domains = failuredomain.Distinct(domains)
+
 	out := make(map[int32]failuredomain.FailureDomain)
 
+	// Keep the failure domains of the Machines first, in index order, while the failure domain has capacity.
+	for i := int32(0); i < replicas; i++ {
+		machineFailureDomain, ok := machines[i]
+		if !ok {
+			continue
+		}
+
+		fd, ok := matchingFailureDomain(domains, machineFailureDomain)
+		if !ok {
+			logger.V(4).Info("Ignoring unknown failure domain", "index", i, "failureDomain", machineFailureDomain.String())
+
+			continue
+		}
+
+		if capacity[fd.Hash()] > 0 {
+			out[i] = fd
+			capacity[fd.Hash()]--
+		}
+	}
+
+	// Fill the remaining indexes with the next failure domain that still has capacity.
+	for i := int32(0); i < replicas; i++ {
+		if _, ok := out[i]; ok {
+			continue
+		}
+
+		for j := int32(0); j < int32(len(domains)); j++ {
+			fd := failuredomain.NextForIndex(i+j, domains)
+
+			if capacity[fd.Hash()] > 0 {
+				out[i] = fd
+				capacity[fd.Hash()]--
+
+				break
+			}
+		}
+
+		if machineFailureDomain, ok := machines[i]; ok {
+			if _, known := matchingFailureDomain(domains, machineFailureDomain); known {
+				logger.V(4).Info("Failure domain changed for index", "index", i, "oldFailureDomain", machineFailureDomain.String(), "newFailureDomain", out[i].String())
+			}
+		}
+	}
+
 	return out
 }
+
+// matchingFailureDomain returns the failure domain, from the failure domains provided, that matches the failure
+// domain extracted from a Machine.
+func matchingFailureDomain(domains []failuredomain.FailureDomain, machineFailureDomain failuredomain.FailureDomain) (failuredomain.FailureDomain, bool) {
+	for _, domain := range domains {
+		// Matches only errors when the platforms differ, in which case the failure domains do not match.
+		if matches, err := domain.Matches(extractedFailureDomain{machineFailureDomain}); err == nil && matches {
+			return domain, true
+		}
+	}
+
+	return nil, false
+}
+
+// extractedFailureDomain wraps a failure domain extracted from a Machine so that it can be compared with
+// the configured failure domains using Matches.
+type extractedFailureDomain struct {
+	failuredomain.FailureDomain
+}
+
+// ExtractFailureDomain returns the failure domain extracted from the Machine.
+func (e extractedFailureDomain) ExtractFailureDomain() failuredomain.FailureDomain {
+	return e.FailureDomain
+}
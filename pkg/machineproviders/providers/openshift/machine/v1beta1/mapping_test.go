@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -585,7 +587,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 
 			Expect(mapping).To(Equal(in.expectedMapping))
 		},
-			PEntry("with no replicas set", createBaseMappingTableInput{
+			Entry("with no replicas set", createBaseMappingTableInput{
 				cpms: &machinev1.ControlPlaneMachineSet{},
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
@@ -594,7 +596,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 				).BuildFailureDomains(),
 				expectedError: errReplicasRequired,
 			}),
-			PEntry("with three replicas and three failure domains (order a,b,c)", createBaseMappingTableInput{
+			Entry("with three replicas and three failure domains (order a,b,c)", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
@@ -607,7 +609,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with three replicas and three failure domains (order b,c,a)", createBaseMappingTableInput{
+			Entry("with three replicas and three failure domains (order b,c,a)", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1bFailureDomainBuilder,
@@ -620,7 +622,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with three replicas and three failure domains (order b,a,c)", createBaseMappingTableInput{
+			Entry("with three replicas and three failure domains (order b,a,c)", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1bFailureDomainBuilder,
@@ -633,7 +635,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with three replicas and one failure domains", createBaseMappingTableInput{
+			Entry("with three replicas and one failure domains", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
@@ -644,7 +646,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with three replicas and two failure domains (order a,b)", createBaseMappingTableInput{
+			Entry("with three replicas and two failure domains (order a,b)", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
@@ -656,7 +658,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with three replicas and two failure domains (order b,a)", createBaseMappingTableInput{
+			Entry("with three replicas and two failure domains (order b,a)", createBaseMappingTableInput{
 				cpms: cpmsBuilder.WithReplicas(3).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1bFailureDomainBuilder,
@@ -668,8 +670,8 @@ var _ = Describe("Failure Domain Mapping", func() {
 					2: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with five replicas and three failure domains (order a,b,c)", createBaseMappingTableInput{
-				cpms: cpmsBuilder.WithReplicas(3).Build(),
+			Entry("with five replicas and three failure domains (order a,b,c)", createBaseMappingTableInput{
+				cpms: cpmsBuilder.WithReplicas(5).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
 					usEast1bFailureDomainBuilder,
@@ -683,8 +685,8 @@ var _ = Describe("Failure Domain Mapping", func() {
 					4: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with five replicas and three failure domains (order b,c,a)", createBaseMappingTableInput{
-				cpms: cpmsBuilder.WithReplicas(3).Build(),
+			Entry("with five replicas and three failure domains (order b,c,a)", createBaseMappingTableInput{
+				cpms: cpmsBuilder.WithReplicas(5).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1bFailureDomainBuilder,
 					usEast1cFailureDomainBuilder,
@@ -698,8 +700,8 @@ var _ = Describe("Failure Domain Mapping", func() {
 					4: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with five replicas and two failure domains (order a,b)", createBaseMappingTableInput{
-				cpms: cpmsBuilder.WithReplicas(3).Build(),
+			Entry("with five replicas and two failure domains (order a,b)", createBaseMappingTableInput{
+				cpms: cpmsBuilder.WithReplicas(5).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1aFailureDomainBuilder,
 					usEast1bFailureDomainBuilder,
@@ -712,8 +714,8 @@ var _ = Describe("Failure Domain Mapping", func() {
 					4: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 				},
 			}),
-			PEntry("with five replicas and two failure domains (order b,a)", createBaseMappingTableInput{
-				cpms: cpmsBuilder.WithReplicas(3).Build(),
+			Entry("with five replicas and two failure domains (order b,a)", createBaseMappingTableInput{
+				cpms: cpmsBuilder.WithReplicas(5).Build(),
 				failureDomains: resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
 					usEast1bFailureDomainBuilder,
 					usEast1aFailureDomainBuilder,
@@ -872,7 +874,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 						Level: 4,
 						KeysAndValues: []interface{}{
 							"oldMachine", "machine-0",
-							"oldFaliureDomain", "us-east-1a",
+							"oldFailureDomain", "us-east-1a",
 							"newerMachine", "machine-replacement-0",
 							"newerFailureDomain", "us-east-1b",
 						},
@@ -894,12 +896,12 @@ var _ = Describe("Failure Domain Mapping", func() {
 		DescribeTable("should keep the machine indexes stable where possible", func(in reconcileMappingsTableInput) {
 			logger := test.NewTestLogger()
 
-			mapping := reconcileMappings(logger.Logger(), in.baseMapping, in.expectedMapping)
+			mapping := reconcileMappings(logger.Logger(), in.baseMapping, in.machineMapping)
 
 			Expect(mapping).To(Equal(in.expectedMapping))
 			Expect(logger.Entries()).To(Equal(in.expectedLogs))
 		},
-			PEntry("when the mappings match", reconcileMappingsTableInput{
+			Entry("when the mappings match", reconcileMappingsTableInput{
 				baseMapping: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
@@ -917,7 +919,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 				},
 				expectedLogs: []test.LogEntry{},
 			}),
-			PEntry("when the mappings differ, machines take precedence (order b,c,a)", reconcileMappingsTableInput{
+			Entry("when the mappings differ, machines take precedence (order b,c,a)", reconcileMappingsTableInput{
 				baseMapping: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
@@ -935,7 +937,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 				},
 				expectedLogs: []test.LogEntry{},
 			}),
-			PEntry("when the mappings differ, machines take precedence (order b,a,c)", reconcileMappingsTableInput{
+			Entry("when the mappings differ, machines take precedence (order b,a,c)", reconcileMappingsTableInput{
 				baseMapping: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
@@ -953,7 +955,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 				},
 				expectedLogs: []test.LogEntry{},
 			}),
-			PEntry("when a machine has a failure domain not in the base mapping", reconcileMappingsTableInput{
+			Entry("when a machine has a failure domain not in the base mapping", reconcileMappingsTableInput{
 				baseMapping: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
@@ -973,14 +975,14 @@ var _ = Describe("Failure Domain Mapping", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"index", 2,
-							"failureDomain", "us-east-1c",
+							"index", int32(2),
+							"failureDomain", failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()).String(),
 						},
 						Message: "Ignoring unknown failure domain",
 					},
 				},
 			}),
-			PEntry("when the base mapping has a failure domain not in the machine mapping", reconcileMappingsTableInput{
+			Entry("when the base mapping has a failure domain not in the machine mapping", reconcileMappingsTableInput{
 				baseMapping: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
 					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
@@ -1000,9 +1002,9 @@ var _ = Describe("Failure Domain Mapping", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"index", 2,
-							"oldFailureDomain", "us-east-1a",
-							"newFailureDomain", "us-east-1c",
+							"index", int32(2),
+							"oldFailureDomain", failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()).String(),
+							"newFailureDomain", failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()).String(),
 						},
 						Message: "Failure domain changed for index",
 					},
@@ -1010,4 +1012,119 @@ var _ = Describe("Failure Domain Mapping", func() {
 			}),
 		)
 	})
+
+	Context("AssignFailureDomains", func() {
+		type assignFailureDomainsTableInput struct {
+			machines        []machinev1beta1.Machine
+			failureDomains  []failuredomain.FailureDomain
+			replicas        int
+			expectedError   error
+			expectedMapping map[int32]failuredomain.FailureDomain
+		}
+
+		usEast1aFailureDomain := failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build())
+		usEast1bFailureDomain := failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build())
+		usEast1cFailureDomain := failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build())
+
+		// machineInFailureDomain builds a Machine with the given name whose provider spec matches the failure domain in the given zone.
+		machineInFailureDomain := func(name, zone string) machinev1beta1.Machine {
+			providerSpec := resourcebuilder.AWSProviderSpec().
+				WithAvailabilityZone(zone).
+				WithSubnet(machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{
+						{
+							Name:   "tag:Name",
+							Values: []string{"subnet-" + zone},
+						},
+					},
+				})
+
+			return *machineBuilder.WithName(name).WithProviderSpecBuilder(providerSpec).Build()
+		}
+
+		DescribeTable("should assign failure domains to indexes", func(in assignFailureDomainsTableInput) {
+			mapping, err := AssignFailureDomains(in.machines, in.failureDomains, in.replicas)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mapping).To(Equal(in.expectedMapping))
+		},
+			Entry("with no existing machines", assignFailureDomainsTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1cFailureDomain, usEast1aFailureDomain, usEast1bFailureDomain},
+				replicas:       3,
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: usEast1aFailureDomain,
+					1: usEast1bFailureDomain,
+					2: usEast1cFailureDomain,
+				},
+			}),
+			Entry("when the existing machines already match the failure domains, the placements are kept", assignFailureDomainsTableInput{
+				machines: []machinev1beta1.Machine{
+					machineInFailureDomain("cluster-master-0", "us-east-1b"),
+					machineInFailureDomain("cluster-master-1", "us-east-1c"),
+					machineInFailureDomain("cluster-master-2", "us-east-1a"),
+				},
+				failureDomains: []failuredomain.FailureDomain{usEast1aFailureDomain, usEast1bFailureDomain, usEast1cFailureDomain},
+				replicas:       3,
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: usEast1bFailureDomain,
+					1: usEast1cFailureDomain,
+					2: usEast1aFailureDomain,
+				},
+			}),
+			Entry("when a failure domain was removed, its machines are rebalanced", assignFailureDomainsTableInput{
+				machines: []machinev1beta1.Machine{
+					machineInFailureDomain("cluster-master-0", "us-east-1a"),
+					machineInFailureDomain("cluster-master-1", "us-east-1b"),
+					machineInFailureDomain("cluster-master-2", "us-east-1c"),
+				},
+				failureDomains: []failuredomain.FailureDomain{usEast1aFailureDomain, usEast1bFailureDomain},
+				replicas:       3,
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: usEast1aFailureDomain,
+					1: usEast1bFailureDomain,
+					2: usEast1aFailureDomain,
+				},
+			}),
+			Entry("when the existing machines are unbalanced, only the balanced placements are kept", assignFailureDomainsTableInput{
+				machines: []machinev1beta1.Machine{
+					machineInFailureDomain("cluster-master-0", "us-east-1a"),
+					machineInFailureDomain("cluster-master-1", "us-east-1a"),
+					machineInFailureDomain("cluster-master-2", "us-east-1a"),
+				},
+				failureDomains: []failuredomain.FailureDomain{usEast1aFailureDomain, usEast1bFailureDomain, usEast1cFailureDomain},
+				replicas:       3,
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: usEast1aFailureDomain,
+					1: usEast1bFailureDomain,
+					2: usEast1cFailureDomain,
+				},
+			}),
+			Entry("with machine names without an index, the machines are ignored", assignFailureDomainsTableInput{
+				machines: []machinev1beta1.Machine{
+					machineInFailureDomain("cluster-master-a", "us-east-1c"),
+				},
+				failureDomains: []failuredomain.FailureDomain{usEast1aFailureDomain, usEast1bFailureDomain, usEast1cFailureDomain},
+				replicas:       3,
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: usEast1aFailureDomain,
+					1: usEast1bFailureDomain,
+					2: usEast1cFailureDomain,
+				},
+			}),
+			Entry("with no failure domains", assignFailureDomainsTableInput{
+				replicas:      3,
+				expectedError: errNoFailureDomains,
+			}),
+			Entry("with no replicas", assignFailureDomainsTableInput{
+				failureDomains: []failuredomain.FailureDomain{usEast1aFailureDomain},
+				replicas:       0,
+				expectedError:  fmt.Errorf("%w: %d", errInvalidReplicas, 0),
+			}),
+		)
+	})
 })
@@ -93,6 +93,12 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 	}, nil
 }
 
+// MachineName returns the name of the control plane Machine for the given index within the cluster.
+// This matches the names of the control plane Machines created by the installer, which end with their index.
+func MachineName(clusterID string, index int32) string {
+	return fmt.Sprintf("%s-master-%d", clusterID, index)
+}
+
 // openshiftMachineProvider holds the implementation of the MachineProvider interface.
 type openshiftMachineProvider struct {
 	// client is used to make API calls to fetch Machines and Nodes.
@@ -1070,3 +1070,15 @@ var _ = Describe("MachineProvider", func() {
 		})
 	})
 })
+
+var _ = Describe("MachineName", func() {
+	DescribeTable("should format the machine name from the cluster ID and index", func(index int32, expectedName string) {
+		Expect(MachineName("cpms-cluster-test-id", index)).To(Equal(expectedName))
+	},
+		Entry("with index 0", int32(0), "cpms-cluster-test-id-master-0"),
+		Entry("with index 1", int32(1), "cpms-cluster-test-id-master-1"),
+		Entry("with index 2", int32(2), "cpms-cluster-test-id-master-2"),
+		Entry("with index 3", int32(3), "cpms-cluster-test-id-master-3"),
+		Entry("with index 4", int32(4), "cpms-cluster-test-id-master-4"),
+	)
+})
@@ -19,11 +19,31 @@ package providerconfig
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// defaultAWSCredentialsSecret is the credentials secret the machine API defaults AWS Machines to use.
+	// This mirrors defaultAWSCredentialsSecret in the machine API operator's Machine defaulting webhook
+	// (openshift/machine-api-operator pkg/webhooks/machine_webhook.go) and must be kept in sync with it.
+	defaultAWSCredentialsSecret = "aws-cloud-credentials"
+
+	// defaultAWSVolumeType is the EBS volume type the machine API uses when none is specified.
+	// This mirrors the volume type the machine API AWS provider (openshift/machine-api-provider-aws)
+	// creates EBS volumes with when the block device omits it, and must be kept in sync with it.
+	defaultAWSVolumeType = "gp2"
+
+	// minimumAWSRootVolumeSize is the smallest root volume size, in GiB, with which a control plane Machine can function.
+	minimumAWSRootVolumeSize = 8
 )
 
 // AWSProviderConfig holds the provider spec of an AWS Machine.
@@ -44,15 +64,154 @@ func (a AWSProviderConfig) InjectFailureDomain(fd machinev1.AWSFailureDomain) AW
 	return newAWSProviderConfig
 }
 
+// Merge returns a new AWSProviderConfig with the placement and subnet taken from the overrides,
+// when they are set. All other fields are taken from the current AWSProviderConfig.
+func (a AWSProviderConfig) Merge(overrides AWSProviderConfig) AWSProviderConfig {
+	newAWSProviderConfig := a
+
+	if overrides.providerConfig.Placement.Region != "" {
+		newAWSProviderConfig.providerConfig.Placement.Region = overrides.providerConfig.Placement.Region
+	}
+
+	if overrides.providerConfig.Placement.AvailabilityZone != "" {
+		newAWSProviderConfig.providerConfig.Placement.AvailabilityZone = overrides.providerConfig.Placement.AvailabilityZone
+	}
+
+	if overrides.providerConfig.Placement.Tenancy != "" {
+		newAWSProviderConfig.providerConfig.Placement.Tenancy = overrides.providerConfig.Placement.Tenancy
+	}
+
+	if !reflect.DeepEqual(overrides.providerConfig.Subnet, machinev1beta1.AWSResourceReference{}) {
+		newAWSProviderConfig.providerConfig.Subnet = overrides.providerConfig.Subnet
+	}
+
+	return newAWSProviderConfig
+}
+
+// Equal compares the AWSProviderConfig with another AWSProviderConfig.
+// Differences in the subnet representation that are introduced when converting between the
+// v1 and v1beta1 AWS resource references, such as nil and empty filter lists, are ignored.
+// Fields omitted on one side and defaulted by the machine API on the other are also ignored.
+func (a AWSProviderConfig) Equal(other AWSProviderConfig) bool {
+	return reflect.DeepEqual(NormalizeAWS(a.normalizedSubnetConfig()), NormalizeAWS(other.normalizedSubnetConfig()))
+}
+
+// NormalizeAWS returns a copy of the AWSMachineProviderConfig with the defaults that the
+// machine API applies set on any fields that were omitted.
+// This allows a template that omits defaultable fields to be compared with an actual Machine.
+func NormalizeAWS(spec machinev1beta1.AWSMachineProviderConfig) machinev1beta1.AWSMachineProviderConfig {
+	normalized := spec.DeepCopy()
+
+	if normalized.CredentialsSecret == nil {
+		normalized.CredentialsSecret = &corev1.LocalObjectReference{
+			Name: defaultAWSCredentialsSecret,
+		}
+	}
+
+	for _, blockDevice := range normalized.BlockDevices {
+		if blockDevice.EBS != nil && (blockDevice.EBS.VolumeType == nil || *blockDevice.EBS.VolumeType == "") {
+			volumeType := defaultAWSVolumeType
+			blockDevice.EBS.VolumeType = &volumeType
+		}
+	}
+
+	return *normalized
+}
+
+// normalizedSubnetConfig returns a copy of the stored AWSMachineProviderConfig with the
+// subnet normalized so that equivalent subnet representations compare equal.
+func (a AWSProviderConfig) normalizedSubnetConfig() machinev1beta1.AWSMachineProviderConfig {
+	config := a.providerConfig
+	config.Subnet = normalizeAWSResourceReference(config.Subnet)
+
+	return config
+}
+
+// Region returns the region of the AWSProviderConfig.
+// When no region is configured, it is derived from the availability zone, which is
+// the region followed by a single letter, for example us-east-1a in us-east-1.
+func (a AWSProviderConfig) Region() string {
+	if a.providerConfig.Placement.Region != "" {
+		return a.providerConfig.Placement.Region
+	}
+
+	az := a.providerConfig.Placement.AvailabilityZone
+	if len(az) < 2 {
+		return ""
+	}
+
+	return az[:len(az)-1]
+}
+
+// Subnet returns the subnet reference of the AWSProviderConfig.
+func (a AWSProviderConfig) Subnet() machinev1beta1.AWSResourceReference {
+	return a.providerConfig.Subnet
+}
+
+// subnetString returns a human readable representation of the subnet reference,
+// in the same format used to print AWS failure domains.
+func (a AWSProviderConfig) subnetString() string {
+	subnet := a.providerConfig.Subnet
+
+	switch {
+	case subnet.ID != nil:
+		return fmt.Sprintf("{Type:%s, Value:%s}", machinev1.AWSIDReferenceType, *subnet.ID)
+	case subnet.Filters != nil:
+		return fmt.Sprintf("{Type:%s, Value:%+v}", machinev1.AWSFiltersReferenceType, subnet.Filters)
+	case subnet.ARN != nil:
+		return fmt.Sprintf("{Type:%s, Value:%s}", machinev1.AWSARNReferenceType, *subnet.ARN)
+	default:
+		return ""
+	}
+}
+
+// SecurityGroups returns an identifier for each security group of the AWSProviderConfig.
+// Security groups referenced by ID or ARN are identified by that value, while security groups
+// referenced by filters are identified by the values of the filters, such as the group names.
+func (a AWSProviderConfig) SecurityGroups() []string {
+	var securityGroups []string
+
+	for _, securityGroup := range a.providerConfig.SecurityGroups {
+		switch {
+		case securityGroup.ID != nil:
+			securityGroups = append(securityGroups, *securityGroup.ID)
+		case securityGroup.ARN != nil:
+			securityGroups = append(securityGroups, *securityGroup.ARN)
+		default:
+			for _, filter := range securityGroup.Filters {
+				securityGroups = append(securityGroups, filter.Values...)
+			}
+		}
+	}
+
+	return securityGroups
+}
+
+// Tags returns the tags of the AWSProviderConfig as a map of tag names to values.
+// When a tag name is repeated, the last value is used.
+func (a AWSProviderConfig) Tags() map[string]string {
+	tags := make(map[string]string, len(a.providerConfig.Tags))
+
+	for _, tag := range a.providerConfig.Tags {
+		tags[tag.Name] = tag.Value
+	}
+
+	return tags
+}
+
+// SetInstanceType returns a new AWSProviderConfig configured with the instance type provided.
+func (a AWSProviderConfig) SetInstanceType(instanceType string) AWSProviderConfig {
+	newAWSProviderConfig := a
+
+	newAWSProviderConfig.providerConfig.InstanceType = instanceType
+
+	return newAWSProviderConfig
+}
+
 // ExtractFailureDomain returns an AWSFailureDomain based on the failure domain
 // information stored within the AWSProviderConfig.
 func (a AWSProviderConfig) ExtractFailureDomain() machinev1.AWSFailureDomain {
-	return machinev1.AWSFailureDomain{
-		Placement: machinev1.AWSFailureDomainPlacement{
-			AvailabilityZone: a.providerConfig.Placement.AvailabilityZone,
-		},
-		Subnet: convertAWSResourceReferenceV1Beta1ToV1(a.providerConfig.Subnet),
-	}
+	return failuredomain.AWSFailureDomainFromProviderConfig(a.providerConfig)
 }
 
 // Config returns the stored AWSMachineProviderConfig.
@@ -60,6 +219,62 @@ func (a AWSProviderConfig) Config() machinev1beta1.AWSMachineProviderConfig {
 	return a.providerConfig
 }
 
+// Validate checks that the AWSProviderConfig has an instance type, boot image and user data secret configured,
+// and that the root volume is large enough for a control plane Machine.
+func (a AWSProviderConfig) Validate() field.ErrorList {
+	errs := field.ErrorList{}
+
+	if a.providerConfig.InstanceType == "" {
+		errs = append(errs, field.Required(field.NewPath("instanceType"), "an instance type must be specified"))
+	}
+
+	if reflect.DeepEqual(a.providerConfig.AMI, machinev1beta1.AWSResourceReference{}) {
+		errs = append(errs, field.Required(field.NewPath("ami"), "a boot image must be specified"))
+	}
+
+	errs = append(errs, validateUserDataSecret(a.providerConfig.UserDataSecret)...)
+	errs = append(errs, a.validateRootVolumeSize()...)
+
+	return errs
+}
+
+// validateRootVolumeSize checks that the root volume, the block device without a device name, is not
+// smaller than the minimum root volume size. When no size is specified, the size of the AMI is used.
+func (a AWSProviderConfig) validateRootVolumeSize() field.ErrorList {
+	for i, blockDevice := range a.providerConfig.BlockDevices {
+		if blockDevice.DeviceName != nil || blockDevice.EBS == nil || blockDevice.EBS.VolumeSize == nil {
+			continue
+		}
+
+		if size := *blockDevice.EBS.VolumeSize; size < minimumAWSRootVolumeSize {
+			return field.ErrorList{
+				field.Invalid(field.NewPath("blockDevices").Index(i).Child("ebs", "volumeSize"), size,
+					fmt.Sprintf("root volume size %d is below the minimum of %d", size, minimumAWSRootVolumeSize)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizedConfig returns a copy of the stored AWSMachineProviderConfig with any
+// unordered collections sorted so that marshalling the config produces a stable output.
+// Maps are already marshalled with sorted keys, but AWS tags are stored as a list.
+func (a AWSProviderConfig) normalizedConfig() machinev1beta1.AWSMachineProviderConfig {
+	config := a.providerConfig
+
+	if config.Tags != nil {
+		config.Tags = make([]machinev1beta1.TagSpecification, len(a.providerConfig.Tags))
+		copy(config.Tags, a.providerConfig.Tags)
+
+		sort.SliceStable(config.Tags, func(i, j int) bool {
+			return config.Tags[i].Name < config.Tags[j].Name
+		})
+	}
+
+	return config
+}
+
 // newAWSProviderConfig creates an AWS type ProviderConfig from the raw extension.
 // It should return an error if the provided RawExtension does not represent
 // an AWSMachineProviderConfig.
@@ -81,39 +296,28 @@ func newAWSProviderConfig(raw *runtime.RawExtension) (ProviderConfig, error) {
 	return config, nil
 }
 
-// ConvertAWSResourceReferenceV1Beta1ToV1 creates a machinev1.awsResourceReference from machinev1beta1.awsResourceReference.
-func convertAWSResourceReferenceV1Beta1ToV1(referenceV1Beta1 machinev1beta1.AWSResourceReference) *machinev1.AWSResourceReference {
-	referenceV1 := &machinev1.AWSResourceReference{}
-
-	if referenceV1Beta1.ID != nil {
-		referenceV1.Type = machinev1.AWSIDReferenceType
-		referenceV1.ID = referenceV1Beta1.ID
+// normalizeAWSResourceReference returns a copy of the AWS resource reference with empty
+// filter and filter value lists replaced by nil, so that equivalent references can be compared.
+func normalizeAWSResourceReference(reference machinev1beta1.AWSResourceReference) machinev1beta1.AWSResourceReference {
+	if len(reference.Filters) == 0 {
+		reference.Filters = nil
 
-		return referenceV1
+		return reference
 	}
 
-	if referenceV1Beta1.Filters != nil {
-		referenceV1.Type = machinev1.AWSFiltersReferenceType
+	filters := make([]machinev1beta1.Filter, len(reference.Filters))
 
-		referenceV1.Filters = &[]machinev1.AWSResourceFilter{}
-		for _, filter := range referenceV1Beta1.Filters {
-			*referenceV1.Filters = append(*referenceV1.Filters, machinev1.AWSResourceFilter{
-				Name:   filter.Name,
-				Values: filter.Values,
-			})
-		}
+	for i, filter := range reference.Filters {
+		filters[i] = filter
 
-		return referenceV1
+		if len(filter.Values) == 0 {
+			filters[i].Values = nil
+		}
 	}
 
-	if referenceV1Beta1.ARN != nil {
-		referenceV1.Type = machinev1.AWSARNReferenceType
-		referenceV1.ARN = referenceV1Beta1.ARN
+	reference.Filters = filters
 
-		return referenceV1
-	}
-
-	return nil
+	return reference
 }
 
 // ConvertAWSResourceReferenceV1ToV1Beta1 creates a machinev1beta1.awsResourceReference from machinev1.awsResourceReference.
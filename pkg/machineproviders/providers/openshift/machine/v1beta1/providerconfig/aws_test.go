@@ -17,12 +17,15 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
 )
 
@@ -89,6 +92,83 @@ var _ = Describe("AWS Provider Config", func() {
 		})
 	})
 
+	Context("ExtractFailureDomain with different subnet reference types", func() {
+		type extractFailureDomainTableInput struct {
+			subnet         machinev1beta1.AWSResourceReference
+			expectedSubnet machinev1.AWSResourceReference
+		}
+
+		DescribeTable("preserves the subnet reference type", func(in extractFailureDomainTableInput) {
+			config := AWSProviderConfig{
+				providerConfig: *resourcebuilder.AWSProviderSpec().
+					WithAvailabilityZone(azUSEast1a).
+					WithSubnet(in.subnet).
+					Build(),
+			}
+
+			expected := resourcebuilder.AWSFailureDomain().
+				WithAvailabilityZone(azUSEast1a).
+				WithSubnet(in.expectedSubnet).
+				Build()
+
+			Expect(config.ExtractFailureDomain()).To(Equal(expected))
+		},
+			Entry("with a filter type subnet", extractFailureDomainTableInput{
+				subnet:         machinev1beta1SubnetUSEast1a,
+				expectedSubnet: machinev1SubnetUSEast1a,
+			}),
+			Entry("with an id type subnet", extractFailureDomainTableInput{
+				subnet: machinev1beta1.AWSResourceReference{
+					ID: stringPtr("subnet-12345678"),
+				},
+				expectedSubnet: machinev1.AWSResourceReference{
+					Type: machinev1.AWSIDReferenceType,
+					ID:   stringPtr("subnet-12345678"),
+				},
+			}),
+			Entry("with an arn type subnet", extractFailureDomainTableInput{
+				subnet: machinev1beta1.AWSResourceReference{
+					ARN: stringPtr("arn:aws:ec2:us-east-1:123456789012:subnet/subnet-12345678"),
+				},
+				expectedSubnet: machinev1.AWSResourceReference{
+					Type: machinev1.AWSARNReferenceType,
+					ARN:  stringPtr("arn:aws:ec2:us-east-1:123456789012:subnet/subnet-12345678"),
+				},
+			}),
+		)
+
+		type compareFailureDomainsTableInput struct {
+			subnetA       machinev1beta1.AWSResourceReference
+			subnetB       machinev1beta1.AWSResourceReference
+			expectedEqual bool
+		}
+
+		DescribeTable("compares extracted id type subnets by their ID", func(in compareFailureDomainsTableInput) {
+			configA := AWSProviderConfig{
+				providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone(azUSEast1a).WithSubnet(in.subnetA).Build(),
+			}
+			configB := AWSProviderConfig{
+				providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone(azUSEast1a).WithSubnet(in.subnetB).Build(),
+			}
+
+			fdA := failuredomain.NewAWSFailureDomain(configA.ExtractFailureDomain())
+			fdB := failuredomain.NewAWSFailureDomain(configB.ExtractFailureDomain())
+
+			Expect(fdA.Equal(fdB)).To(Equal(in.expectedEqual))
+		},
+			Entry("with matching IDs", compareFailureDomainsTableInput{
+				subnetA:       machinev1beta1.AWSResourceReference{ID: stringPtr("subnet-12345678")},
+				subnetB:       machinev1beta1.AWSResourceReference{ID: stringPtr("subnet-12345678")},
+				expectedEqual: true,
+			}),
+			Entry("with different IDs", compareFailureDomainsTableInput{
+				subnetA:       machinev1beta1.AWSResourceReference{ID: stringPtr("subnet-12345678")},
+				subnetB:       machinev1beta1.AWSResourceReference{ID: stringPtr("subnet-87654321")},
+				expectedEqual: false,
+			}),
+		)
+	})
+
 	Context("when the failuredomain is changed after initialisation", func() {
 		var changedProviderConfig AWSProviderConfig
 
@@ -130,6 +210,125 @@ var _ = Describe("AWS Provider Config", func() {
 		})
 	})
 
+	Context("when injecting a failure domain into a fully populated provider config", func() {
+		var populatedProviderConfig AWSProviderConfig
+		var changedProviderConfig AWSProviderConfig
+
+		BeforeEach(func() {
+			machineProviderConfig := resourcebuilder.AWSProviderSpec().
+				WithAvailabilityZone(azUSEast1a).
+				WithSubnet(machinev1beta1SubnetUSEast1a).
+				WithSecurityGroups([]machinev1beta1.AWSResourceReference{
+					{
+						Filters: []machinev1beta1.Filter{
+							{
+								Name:   "tag:Name",
+								Values: []string{"aws-security-group-12345678"},
+							},
+						},
+					},
+				}).
+				Build()
+
+			Expect(machineProviderConfig.IAMInstanceProfile).ToNot(BeNil())
+			Expect(machineProviderConfig.BlockDevices).ToNot(BeEmpty())
+
+			populatedProviderConfig = AWSProviderConfig{
+				providerConfig: *machineProviderConfig,
+			}
+
+			changedFailureDomain := resourcebuilder.AWSFailureDomain().
+				WithAvailabilityZone(azUSEast1b).
+				WithSubnet(machinev1SubnetUSEast1b).
+				Build()
+
+			changedProviderConfig = populatedProviderConfig.InjectFailureDomain(changedFailureDomain)
+		})
+
+		// marshalField returns the JSON representation of a provider config field so that
+		// fields can be compared byte for byte.
+		marshalField := func(field interface{}) []byte {
+			raw, err := json.Marshal(field)
+			Expect(err).ToNot(HaveOccurred())
+
+			return raw
+		}
+
+		It("does not modify the IAM instance profile", func() {
+			Expect(marshalField(changedProviderConfig.Config().IAMInstanceProfile)).To(Equal(marshalField(populatedProviderConfig.Config().IAMInstanceProfile)))
+		})
+
+		It("does not modify the security groups", func() {
+			Expect(marshalField(changedProviderConfig.Config().SecurityGroups)).To(Equal(marshalField(populatedProviderConfig.Config().SecurityGroups)))
+		})
+
+		It("does not modify the block devices", func() {
+			Expect(marshalField(changedProviderConfig.Config().BlockDevices)).To(Equal(marshalField(populatedProviderConfig.Config().BlockDevices)))
+		})
+
+		It("only modifies the placement and subnet", func() {
+			expected := populatedProviderConfig.Config()
+			expected.Placement.AvailabilityZone = azUSEast1b
+			expected.Subnet = machinev1beta1SubnetUSEast1b
+
+			Expect(marshalField(changedProviderConfig.Config())).To(Equal(marshalField(expected)))
+		})
+	})
+
+	Context("NormalizeAWS", func() {
+		var templateConfig, machineConfig machinev1beta1.AWSMachineProviderConfig
+
+		BeforeEach(func() {
+			templateConfig = *resourcebuilder.AWSProviderSpec().Build()
+			templateConfig.CredentialsSecret = nil
+			templateConfig.BlockDevices[0].EBS.VolumeType = nil
+
+			machineConfig = *resourcebuilder.AWSProviderSpec().Build()
+			machineConfig.BlockDevices[0].EBS.VolumeType = stringPtr("gp2")
+		})
+
+		It("applies the machine API defaults to omitted fields", func() {
+			normalized := NormalizeAWS(templateConfig)
+
+			Expect(normalized.CredentialsSecret).To(HaveField("Name", "aws-cloud-credentials"))
+			Expect(normalized.BlockDevices[0].EBS.VolumeType).To(HaveValue(Equal("gp2")))
+		})
+
+		It("does not modify the input", func() {
+			NormalizeAWS(templateConfig)
+
+			Expect(templateConfig.CredentialsSecret).To(BeNil())
+			Expect(templateConfig.BlockDevices[0].EBS.VolumeType).To(BeNil())
+		})
+
+		It("considers a template with omitted defaults equal to a defaulted machine", func() {
+			template := AWSProviderConfig{providerConfig: templateConfig}
+			machine := AWSProviderConfig{providerConfig: machineConfig}
+
+			Expect(template.Equal(machine)).To(BeTrue())
+			Expect(machine.Equal(template)).To(BeTrue())
+		})
+
+		It("considers a template with a different explicit volume type not equal to the machine", func() {
+			templateConfig.BlockDevices[0].EBS.VolumeType = stringPtr("gp3")
+
+			template := AWSProviderConfig{providerConfig: templateConfig}
+			machine := AWSProviderConfig{providerConfig: machineConfig}
+
+			Expect(template.Equal(machine)).To(BeFalse())
+		})
+
+		It("considers a template that omits the volume type not equal to a machine with a non-default volume type", func() {
+			machineConfig.BlockDevices[0].EBS.VolumeType = stringPtr("io1")
+
+			template := AWSProviderConfig{providerConfig: templateConfig}
+			machine := AWSProviderConfig{providerConfig: machineConfig}
+
+			Expect(template.Equal(machine)).To(BeFalse())
+			Expect(machine.Equal(template)).To(BeFalse())
+		})
+	})
+
 	Context("newAWSProviderConfig", func() {
 		var providerConfig ProviderConfig
 		var expectedAWSConfig machinev1beta1.AWSMachineProviderConfig
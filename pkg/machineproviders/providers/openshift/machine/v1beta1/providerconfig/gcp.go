@@ -0,0 +1,160 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// minimumGCPBootDiskSize is the smallest boot disk size, in GB, with which a control plane Machine can function.
+const minimumGCPBootDiskSize = 16
+
+// GCPProviderConfig holds the provider spec of a GCP Machine.
+// It allows external code to extract and inject failure domain information,
+// as well as gathering the stored config.
+type GCPProviderConfig struct {
+	providerConfig machinev1beta1.GCPMachineProviderSpec
+}
+
+// InjectFailureDomain returns a new GCPProviderConfig configured with the failure domain
+// information provided.
+// When the region is not set, it is derived from the zone so that the two remain consistent.
+func (g GCPProviderConfig) InjectFailureDomain(fd machinev1.GCPFailureDomain) GCPProviderConfig {
+	newGCPProviderConfig := g
+
+	newGCPProviderConfig.providerConfig.Zone = fd.Zone
+
+	if newGCPProviderConfig.providerConfig.Region == "" {
+		newGCPProviderConfig.providerConfig.Region = gcpRegionFromZone(fd.Zone)
+	}
+
+	return newGCPProviderConfig
+}
+
+// Merge returns a new GCPProviderConfig with the region and zone taken from the overrides,
+// when they are set. All other fields are taken from the current GCPProviderConfig.
+func (g GCPProviderConfig) Merge(overrides GCPProviderConfig) GCPProviderConfig {
+	newGCPProviderConfig := g
+
+	if overrides.providerConfig.Region != "" {
+		newGCPProviderConfig.providerConfig.Region = overrides.providerConfig.Region
+	}
+
+	if overrides.providerConfig.Zone != "" {
+		newGCPProviderConfig.providerConfig.Zone = overrides.providerConfig.Zone
+	}
+
+	return newGCPProviderConfig
+}
+
+// ExtractFailureDomain returns a GCPFailureDomain based on the failure domain
+// information stored within the GCPProviderConfig.
+func (g GCPProviderConfig) ExtractFailureDomain() machinev1.GCPFailureDomain {
+	return failuredomain.GCPFailureDomainFromProviderSpec(g.providerConfig)
+}
+
+// SetInstanceType returns a new GCPProviderConfig configured with the machine type provided.
+func (g GCPProviderConfig) SetInstanceType(machineType string) GCPProviderConfig {
+	newGCPProviderConfig := g
+
+	newGCPProviderConfig.providerConfig.MachineType = machineType
+
+	return newGCPProviderConfig
+}
+
+// Validate checks that the GCPProviderConfig has a machine type, boot image and user data secret configured,
+// and that the boot disk is large enough for a control plane Machine.
+func (g GCPProviderConfig) Validate() field.ErrorList {
+	errs := field.ErrorList{}
+
+	if g.providerConfig.MachineType == "" {
+		errs = append(errs, field.Required(field.NewPath("machineType"), "a machine type must be specified"))
+	}
+
+	hasBootImage := false
+
+	for i, disk := range g.providerConfig.Disks {
+		if disk == nil || !disk.Boot {
+			continue
+		}
+
+		// A size of zero uses the size of the boot image.
+		if disk.SizeGB != 0 && disk.SizeGB < minimumGCPBootDiskSize {
+			errs = append(errs, field.Invalid(field.NewPath("disks").Index(i).Child("sizeGb"), disk.SizeGB,
+				fmt.Sprintf("root volume size %d is below the minimum of %d", disk.SizeGB, minimumGCPBootDiskSize)))
+		}
+
+		if disk.Image != "" {
+			hasBootImage = true
+		}
+	}
+
+	if !hasBootImage {
+		errs = append(errs, field.Required(field.NewPath("disks"), "a boot image must be specified"))
+	}
+
+	errs = append(errs, validateUserDataSecret(g.providerConfig.UserDataSecret)...)
+
+	return errs
+}
+
+// Config returns the stored GCPMachineProviderSpec.
+func (g GCPProviderConfig) Config() machinev1beta1.GCPMachineProviderSpec {
+	return g.providerConfig
+}
+
+// newGCPProviderConfig creates a GCP type ProviderConfig from the raw extension.
+// It should return an error if the provided RawExtension does not represent
+// a GCPMachineProviderSpec.
+func newGCPProviderConfig(raw *runtime.RawExtension) (ProviderConfig, error) {
+	gcpMachineProviderSpec := machinev1beta1.GCPMachineProviderSpec{}
+	if err := json.Unmarshal(raw.Raw, &gcpMachineProviderSpec); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider spec: %w", err)
+	}
+
+	gcpProviderConfig := GCPProviderConfig{
+		providerConfig: gcpMachineProviderSpec,
+	}
+
+	config := providerConfig{
+		platformType: configv1.GCPPlatformType,
+		gcp:          gcpProviderConfig,
+	}
+
+	return config, nil
+}
+
+// gcpRegionFromZone derives the GCP region from the zone name.
+// GCP zones are named after their region with a single suffix, for example
+// the zone us-central1-b is within the region us-central1.
+func gcpRegionFromZone(zone string) string {
+	idx := strings.LastIndex(zone, "-")
+	if idx < 0 {
+		return ""
+	}
+
+	return zone[:idx]
+}
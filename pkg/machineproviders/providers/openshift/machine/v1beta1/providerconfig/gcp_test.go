@@ -0,0 +1,135 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
+)
+
+var _ = Describe("GCP Provider Config", func() {
+	var providerConfig GCPProviderConfig
+
+	usCentral1a := "us-central1-a"
+	usCentral1b := "us-central1-b"
+
+	BeforeEach(func() {
+		machineProviderConfig := resourcebuilder.GCPProviderSpec().
+			WithZone(usCentral1a).
+			Build()
+
+		providerConfig = GCPProviderConfig{
+			providerConfig: *machineProviderConfig,
+		}
+	})
+
+	Context("ExtractFailureDomain", func() {
+		It("returns the configured failure domain", func() {
+			expected := resourcebuilder.GCPFailureDomain().
+				WithZone(usCentral1a).
+				Build()
+
+			Expect(providerConfig.ExtractFailureDomain()).To(Equal(expected))
+		})
+	})
+
+	Context("when the failuredomain is changed after initialisation", func() {
+		var changedProviderConfig GCPProviderConfig
+
+		BeforeEach(func() {
+			changedFailureDomain := resourcebuilder.GCPFailureDomain().
+				WithZone(usCentral1b).
+				Build()
+
+			changedProviderConfig = providerConfig.InjectFailureDomain(changedFailureDomain)
+		})
+
+		It("stores the new zone in the provider config", func() {
+			Expect(changedProviderConfig.Config().Zone).To(Equal(usCentral1b))
+		})
+
+		It("keeps the existing region in the provider config", func() {
+			Expect(changedProviderConfig.Config().Region).To(Equal("us-central1"))
+		})
+
+		It("does not modify the original provider config", func() {
+			Expect(providerConfig.Config().Zone).To(Equal(usCentral1a))
+		})
+
+		Context("ExtractFailureDomain", func() {
+			It("returns the changed failure domain from the changed config", func() {
+				expected := resourcebuilder.GCPFailureDomain().
+					WithZone(usCentral1b).
+					Build()
+
+				Expect(changedProviderConfig.ExtractFailureDomain()).To(Equal(expected))
+			})
+		})
+	})
+
+	Context("when the failuredomain is injected into a config without a region", func() {
+		var changedProviderConfig GCPProviderConfig
+
+		BeforeEach(func() {
+			providerConfig = GCPProviderConfig{
+				providerConfig: *resourcebuilder.GCPProviderSpec().WithZone(usCentral1a).WithRegion("").Build(),
+			}
+
+			changedFailureDomain := resourcebuilder.GCPFailureDomain().
+				WithZone(usCentral1b).
+				Build()
+
+			changedProviderConfig = providerConfig.InjectFailureDomain(changedFailureDomain)
+		})
+
+		It("stores the new zone in the provider config", func() {
+			Expect(changedProviderConfig.Config().Zone).To(Equal(usCentral1b))
+		})
+
+		It("derives the region from the zone", func() {
+			Expect(changedProviderConfig.Config().Region).To(Equal("us-central1"))
+		})
+	})
+
+	Context("newGCPProviderConfig", func() {
+		var providerConfig ProviderConfig
+		var expectedGCPConfig machinev1beta1.GCPMachineProviderSpec
+
+		BeforeEach(func() {
+			configBuilder := resourcebuilder.GCPProviderSpec()
+			expectedGCPConfig = *configBuilder.Build()
+			rawConfig := configBuilder.BuildRawExtension()
+
+			var err error
+			providerConfig, err = newGCPProviderConfig(rawConfig)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the type to GCP", func() {
+			Expect(providerConfig.Type()).To(Equal(configv1.GCPPlatformType))
+		})
+
+		It("returns the correct GCP config", func() {
+			Expect(providerConfig.GCP()).ToNot(BeNil())
+			Expect(providerConfig.GCP().Config()).To(Equal(expectedGCPConfig))
+		})
+	})
+})
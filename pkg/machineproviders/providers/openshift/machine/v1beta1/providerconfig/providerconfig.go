@@ -17,16 +17,24 @@ limitations under the License.
 package providerconfig
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 var (
@@ -43,6 +51,14 @@ var (
 	errUnknownProviderConfigType = errors.New("unknown provider config type")
 )
 
+// providerConfigConstructors maps each supported platform type to the constructor
+// used to create a ProviderConfig from a raw provider spec.
+// New platform implementations must be registered here.
+var providerConfigConstructors = map[configv1.PlatformType]func(*runtime.RawExtension) (ProviderConfig, error){ //nolint:gochecknoglobals // This is only read after initialisation.
+	configv1.AWSPlatformType: newAWSProviderConfig,
+	configv1.GCPPlatformType: newGCPProviderConfig,
+}
+
 // ProviderConfig is an interface that allows external code to interact
 // with provider configuration across different platform types.
 type ProviderConfig interface {
@@ -51,22 +67,95 @@ type ProviderConfig interface {
 	// the new failure domain injected.
 	InjectFailureDomain(failuredomain.FailureDomain) (ProviderConfig, error)
 
+	// InjectFailureDomains is used to inject each of the failure domains into a copy of the ProviderConfig.
+	// The returned ProviderConfigs are in the same order as the failure domains provided.
+	InjectFailureDomains([]failuredomain.FailureDomain) ([]ProviderConfig, error)
+
 	// ExtractFailureDomain is used to extract a failure domain from the ProviderConfig.
 	ExtractFailureDomain() failuredomain.FailureDomain
 
+	// SetInstanceType is used to set the instance type within the ProviderConfig.
+	// The returned ProviderConfig will be a copy of the current ProviderConfig with
+	// the new instance type set.
+	SetInstanceType(string) (ProviderConfig, error)
+
+	// Merge overlays the failure domain related fields that are set within the overrides
+	// onto a copy of the ProviderConfig. All other fields are taken from the current ProviderConfig.
+	Merge(overrides ProviderConfig) (ProviderConfig, error)
+
 	// Equal compares two ProviderConfigs to determine whether or not they are equal.
 	Equal(ProviderConfig) (bool, error)
 
+	// EqualIgnoringFields compares two ProviderConfigs to determine whether or not they are equal,
+	// ignoring the given fields. Fields are identified by their JSON path within the provider spec,
+	// with nested fields separated by dots, for example "userDataSecret" or "placement.region".
+	EqualIgnoringFields(ProviderConfig, ...string) (bool, error)
+
+	// DiffFields returns the paths of the fields that differ between two ProviderConfigs.
+	// Paths use the Go field names of the platform provider spec, with nested fields separated
+	// by dots, for example "InstanceType" or "Placement.AvailabilityZone".
+	DiffFields(ProviderConfig) ([]string, error)
+
+	// Validate checks the ProviderConfig against the rules for its platform.
+	// The paths of the returned errors are relative to the provider spec value.
+	Validate() field.ErrorList
+
 	// RawConfig marshalls the configuration into a JSON byte slice.
 	RawConfig() ([]byte, error)
 
+	// RawConfigIndent marshalls the configuration into an indented JSON byte slice.
+	// The content is the same as RawConfig, formatted for readability.
+	RawConfigIndent() ([]byte, error)
+
 	// Type returns the platform type of the provider config.
 	Type() configv1.PlatformType
 
+	// InstanceType returns the instance type, or machine type, configured within the provider config.
+	// An empty string is returned for platforms without instance types.
+	InstanceType() string
+
+	// Zone returns the availability zone or zone targeted by the provider config.
+	// An empty string is returned for platforms without zones.
+	Zone() string
+
+	// Region returns the region targeted by the provider config.
+	// An empty string is returned for platforms without regions.
+	Region() string
+
+	// SubnetString returns a human readable representation of the subnet targeted by the
+	// provider config, intended for logging. An empty string is returned when no subnet is set.
+	SubnetString() string
+
+	// Tags returns the tags configured within the provider config as key value pairs.
+	// An empty map is returned for platforms without key value tags.
+	Tags() map[string]string
+
+	// SecurityGroups returns the identifiers of the security groups configured within the provider config.
+	// An empty list is returned for platforms without security groups.
+	SecurityGroups() []string
+
+	// SupportsFailureDomains returns whether the platform of the provider config has a
+	// failure domain concept. Platforms without one should not be spread across failure domains.
+	SupportsFailureDomains() bool
+
 	// AWS returns the AWSProviderConfig if the platform type is AWS.
 	AWS() AWSProviderConfig
+
+	// AWSConfig returns the AWSProviderConfig, or an error if the platform type is not AWS.
+	AWSConfig() (AWSProviderConfig, error)
+
+	// GCP returns the GCPProviderConfig if the platform type is GCP.
+	GCP() GCPProviderConfig
+
+	// GCPConfig returns the GCPProviderConfig, or an error if the platform type is not GCP.
+	GCPConfig() (GCPProviderConfig, error)
 }
 
+var _ ProviderConfig = providerConfig{}
+
+// ProviderConfigs can be passed to FailureDomain.Matches.
+var _ failuredomain.Extractor = providerConfig{}
+
 // NewProviderConfigFromMachineTemplate creates a new ProviderConfig from the provided machine template.
 func NewProviderConfigFromMachineTemplate(tmpl machinev1.OpenShiftMachineV1Beta1MachineTemplate) (ProviderConfig, error) {
 	platformType, err := getPlatformTypeFromMachineTemplate(tmpl)
@@ -79,27 +168,114 @@ func NewProviderConfigFromMachineTemplate(tmpl machinev1.OpenShiftMachineV1Beta1
 
 // NewProviderConfigFromMachine creates a new ProviderConfig from the provided machine object.
 func NewProviderConfigFromMachine(machine machinev1beta1.Machine) (ProviderConfig, error) {
-	platformType, err := getPlatformTypeFromProviderSpec(machine.Spec.ProviderSpec)
+	return NewProviderConfigFromMachineWithContext(context.Background(), machine)
+}
+
+// NewProviderConfigFromMachineWithContext creates a new ProviderConfig from the provided machine object.
+// The context allows any lookups required to determine the provider config to be cancelled.
+// The detected kind, platform and failure domain of the provider spec are logged at V(4) using
+// the logger from the context, if any.
+func NewProviderConfigFromMachineWithContext(ctx context.Context, machine machinev1beta1.Machine) (ProviderConfig, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not create provider config for machine %s: %w", machine.Name, err)
+	}
+
+	logger := logr.FromContextOrDiscard(ctx).WithValues("machineName", machine.Name)
+
+	if kind, err := providerSpecKind(machine.Spec.ProviderSpec); err == nil {
+		platformType, _ := PlatformForKind(kind)
+		logger = logger.WithValues("kind", kind, "platform", platformType)
+	}
+
+	providerConfig, err := NewProviderConfigFromMachineSpec(machine.Spec)
 	if err != nil {
-		return nil, fmt.Errorf("could not determine platform type: %w", err)
+		logger.V(4).Info("Could not parse provider config", "error", err.Error())
+
+		return nil, err
 	}
 
-	return newProviderConfigFromProviderSpec(machine.Spec.ProviderSpec, platformType)
+	logger.V(4).Info("Parsed provider config", "hasFailureDomain", hasFailureDomain(providerConfig.ExtractFailureDomain()))
+
+	return providerConfig, nil
 }
 
-func newProviderConfigFromProviderSpec(providerSpec machinev1beta1.ProviderSpec, platformType configv1.PlatformType) (ProviderConfig, error) {
-	switch platformType {
+// hasFailureDomain returns whether the failure domain holds any failure domain information,
+// for example an AWS failure domain with only a subnet configured.
+func hasFailureDomain(fd failuredomain.FailureDomain) bool {
+	if fd == nil {
+		return false
+	}
+
+	switch fd.Type() {
 	case configv1.AWSPlatformType:
-		return newAWSProviderConfig(providerSpec.Value)
+		return !reflect.DeepEqual(fd.AWS(), machinev1.AWSFailureDomain{})
+	case configv1.AzurePlatformType:
+		return !reflect.DeepEqual(fd.Azure(), machinev1.AzureFailureDomain{})
+	case configv1.GCPPlatformType:
+		return !reflect.DeepEqual(fd.GCP(), machinev1.GCPFailureDomain{})
+	case configv1.OpenStackPlatformType:
+		return !reflect.DeepEqual(fd.OpenStack(), machinev1.OpenStackFailureDomain{})
 	default:
+		return false
+	}
+}
+
+// NewProviderConfigFromMachineSpec creates a new ProviderConfig from the provided machine spec.
+func NewProviderConfigFromMachineSpec(spec machinev1beta1.MachineSpec) (ProviderConfig, error) {
+	platformType, err := PlatformTypeFromProviderSpec(spec.ProviderSpec)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine platform type: %w", err)
+	}
+
+	return newProviderConfigFromProviderSpec(spec.ProviderSpec, platformType)
+}
+
+func newProviderConfigFromProviderSpec(providerSpec machinev1beta1.ProviderSpec, platformType configv1.PlatformType) (ProviderConfig, error) {
+	newProviderConfig, ok := providerConfigConstructors[platformType]
+	if !ok {
 		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, platformType)
 	}
+
+	return newProviderConfig(providerSpec.Value)
+}
+
+// SupportedPlatforms returns the platform types that have a provider config implementation.
+// The platforms are returned in alphabetical order.
+func SupportedPlatforms() []configv1.PlatformType {
+	platforms := []configv1.PlatformType{}
+
+	for platform := range providerConfigConstructors {
+		platforms = append(platforms, platform)
+	}
+
+	sort.Slice(platforms, func(i, j int) bool {
+		return platforms[i] < platforms[j]
+	})
+
+	return platforms
+}
+
+// RawExtensionsEqual decodes both raw extensions as provider configs of the given platform type
+// and compares them using Equal.
+func RawExtensionsEqual(a, b runtime.RawExtension, platform configv1.PlatformType) (bool, error) {
+	aConfig, err := newProviderConfigFromProviderSpec(machinev1beta1.ProviderSpec{Value: &a}, platform)
+	if err != nil {
+		return false, fmt.Errorf("could not decode first provider config: %w", err)
+	}
+
+	bConfig, err := newProviderConfigFromProviderSpec(machinev1beta1.ProviderSpec{Value: &b}, platform)
+	if err != nil {
+		return false, fmt.Errorf("could not decode second provider config: %w", err)
+	}
+
+	return aConfig.Equal(bConfig)
 }
 
 // providerConfig is an implementation of the ProviderConfig interface.
 type providerConfig struct {
 	platformType configv1.PlatformType
 	aws          AWSProviderConfig
+	gcp          GCPProviderConfig
 }
 
 // InjectFailureDomain is used to inject a failure domain into the ProviderConfig.
@@ -111,6 +287,45 @@ func (p providerConfig) InjectFailureDomain(fd failuredomain.FailureDomain) (Pro
 	switch p.platformType {
 	case configv1.AWSPlatformType:
 		newConfig.aws = p.AWS().InjectFailureDomain(fd.AWS())
+	case configv1.GCPPlatformType:
+		newConfig.gcp = p.GCP().InjectFailureDomain(fd.GCP())
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, p.platformType)
+	}
+
+	return newConfig, nil
+}
+
+// InjectFailureDomains is used to inject each of the failure domains into a copy of the ProviderConfig.
+// The returned ProviderConfigs are in the same order as the failure domains provided.
+// If any failure domain cannot be injected, an error is returned identifying the index of the
+// failure domain that could not be injected.
+func (p providerConfig) InjectFailureDomains(fds []failuredomain.FailureDomain) ([]ProviderConfig, error) {
+	configs := []ProviderConfig{}
+
+	for i, fd := range fds {
+		config, err := p.InjectFailureDomain(fd)
+		if err != nil {
+			return nil, fmt.Errorf("could not inject failure domain at index %d: %w", i, err)
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// SetInstanceType is used to set the instance type within the ProviderConfig.
+// The returned ProviderConfig will be a copy of the current ProviderConfig with
+// the new instance type set.
+func (p providerConfig) SetInstanceType(instanceType string) (ProviderConfig, error) {
+	newConfig := p
+
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		newConfig.aws = p.AWS().SetInstanceType(instanceType)
+	case configv1.GCPPlatformType:
+		newConfig.gcp = p.GCP().SetInstanceType(instanceType)
 	default:
 		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, p.platformType)
 	}
@@ -123,11 +338,34 @@ func (p providerConfig) ExtractFailureDomain() failuredomain.FailureDomain {
 	switch p.platformType {
 	case configv1.AWSPlatformType:
 		return failuredomain.NewAWSFailureDomain(p.AWS().ExtractFailureDomain())
+	case configv1.GCPPlatformType:
+		return failuredomain.NewGCPFailureDomain(p.GCP().ExtractFailureDomain())
 	default:
 		return nil
 	}
 }
 
+// Merge overlays the failure domain related fields that are set within the overrides
+// onto a copy of the ProviderConfig. All other fields are taken from the current ProviderConfig.
+func (p providerConfig) Merge(overrides ProviderConfig) (ProviderConfig, error) {
+	if p.platformType != overrides.Type() {
+		return nil, errMismatchedPlatformTypes
+	}
+
+	newConfig := p
+
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		newConfig.aws = p.AWS().Merge(overrides.AWS())
+	case configv1.GCPPlatformType:
+		newConfig.gcp = p.GCP().Merge(overrides.GCP())
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedPlatformType, p.platformType)
+	}
+
+	return newConfig, nil
+}
+
 // Equal compares two ProviderConfigs to determine whether or not they are equal.
 func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
 	if p.platformType != other.Type() {
@@ -136,13 +374,186 @@ func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
 
 	switch p.platformType {
 	case configv1.AWSPlatformType:
-		return reflect.DeepEqual(p.aws.providerConfig, other.AWS().providerConfig), nil
+		return p.aws.Equal(other.AWS()), nil
+	case configv1.GCPPlatformType:
+		return reflect.DeepEqual(p.gcp.providerConfig, other.GCP().providerConfig), nil
 	default:
 		return false, errUnsupportedPlatformType
 	}
 }
 
+// EqualIgnoringFields compares two ProviderConfigs to determine whether or not they are equal,
+// ignoring the given fields.
+// The configurations are compared using their JSON representation, so that fields can be
+// ignored irrespective of the platform.
+func (p providerConfig) EqualIgnoringFields(other ProviderConfig, fields ...string) (bool, error) {
+	if p.platformType != other.Type() {
+		return false, errMismatchedPlatformTypes
+	}
+
+	base, err := rawConfigToMap(p)
+	if err != nil {
+		return false, fmt.Errorf("could not convert first provider config: %w", err)
+	}
+
+	compare, err := rawConfigToMap(other)
+	if err != nil {
+		return false, fmt.Errorf("could not convert second provider config: %w", err)
+	}
+
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+
+		removeField(base, path)
+		removeField(compare, path)
+	}
+
+	return reflect.DeepEqual(base, compare), nil
+}
+
+// DiffFields returns the sorted paths of the fields that differ between two ProviderConfigs.
+// Tags are compared by key, so that each tag added, removed or changed is reported as Tags[key].
+func (p providerConfig) DiffFields(other ProviderConfig) ([]string, error) {
+	if p.platformType != other.Type() {
+		return nil, errMismatchedPlatformTypes
+	}
+
+	var base, compare interface{}
+
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		baseConfig, compareConfig := NormalizeAWS(p.aws.normalizedSubnetConfig()), NormalizeAWS(other.AWS().normalizedSubnetConfig())
+
+		// The tags are compared by key using diffTags below.
+		baseConfig.Tags, compareConfig.Tags = nil, nil
+		base, compare = baseConfig, compareConfig
+	case configv1.GCPPlatformType:
+		base, compare = p.gcp.providerConfig, other.GCP().providerConfig
+	default:
+		return nil, errUnsupportedPlatformType
+	}
+
+	diff := diffFields("", reflect.ValueOf(base), reflect.ValueOf(compare))
+	diff = append(diff, diffTags(p.Tags(), other.Tags())...)
+	sort.Strings(diff)
+
+	return diff, nil
+}
+
+// diffFields walks the two values and returns the paths of the fields that differ.
+// Structs, and pointers to structs, are compared field by field. All other values are
+// compared as a whole, so a difference within a list or map is reported against the field holding it.
+func diffFields(path string, a, b reflect.Value) []string {
+	if a.Kind() == reflect.Ptr && !a.IsNil() && !b.IsNil() {
+		return diffFields(path, a.Elem(), b.Elem())
+	}
+
+	if a.Kind() != reflect.Struct {
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return nil
+		}
+
+		return []string{path}
+	}
+
+	diff := []string{}
+
+	for i := 0; i < a.NumField(); i++ {
+		fieldType := a.Type().Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		fieldPath := fieldType.Name
+		if path != "" {
+			fieldPath = path + "." + fieldType.Name
+		}
+
+		diff = append(diff, diffFields(fieldPath, a.Field(i), b.Field(i))...)
+	}
+
+	return diff
+}
+
+// diffTags returns a Tags[key] path for each tag that is only present in one of the tag sets,
+// or that has a different value in each.
+func diffTags(a, b map[string]string) []string {
+	diff := []string{}
+
+	for key, value := range a {
+		if otherValue, ok := b[key]; !ok || otherValue != value {
+			diff = append(diff, fmt.Sprintf("Tags[%s]", key))
+		}
+	}
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			diff = append(diff, fmt.Sprintf("Tags[%s]", key))
+		}
+	}
+
+	return diff
+}
+
+// rawConfigToMap converts the raw configuration of the ProviderConfig into a generic map.
+func rawConfigToMap(p ProviderConfig) (map[string]interface{}, error) {
+	rawConfig, err := p.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not get raw config: %w", err)
+	}
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(rawConfig, &out); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider config: %w", err)
+	}
+
+	return out, nil
+}
+
+// removeField removes the field at the given path from the map.
+// Missing fields, and paths that traverse non-object values, are ignored.
+func removeField(m map[string]interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+
+	if nested, ok := m[path[0]].(map[string]interface{}); ok {
+		removeField(nested, path[1:])
+	}
+}
+
+// Validate checks the ProviderConfig against the rules for its platform.
+// The paths of the returned errors are relative to the provider spec value.
+// Platforms without validation rules return no errors.
+func (p providerConfig) Validate() field.ErrorList {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.AWS().Validate()
+	case configv1.GCPPlatformType:
+		return p.GCP().Validate()
+	default:
+		return nil
+	}
+}
+
+// validateUserDataSecret checks that the user data secret is referenced by name.
+// Machines cannot be bootstrapped without the user data secret.
+// The credentials secret is not checked, as the Machine API defaults it when it is omitted.
+func validateUserDataSecret(userDataSecret *corev1.LocalObjectReference) field.ErrorList {
+	if userDataSecret == nil || userDataSecret.Name == "" {
+		return field.ErrorList{field.Required(field.NewPath("userDataSecret"), "a user data secret must be specified")}
+	}
+
+	return nil
+}
+
 // RawConfig marshalls the configuration into a JSON byte slice.
+// The output is stable regardless of the order in which tags were added to the configuration.
 func (p providerConfig) RawConfig() ([]byte, error) {
 	var (
 		rawConfig []byte
@@ -151,7 +562,9 @@ func (p providerConfig) RawConfig() ([]byte, error) {
 
 	switch p.platformType {
 	case configv1.AWSPlatformType:
-		rawConfig, err = json.Marshal(p.aws.providerConfig)
+		rawConfig, err = json.Marshal(p.aws.normalizedConfig())
+	case configv1.GCPPlatformType:
+		rawConfig, err = json.Marshal(p.gcp.providerConfig)
 	default:
 		return nil, errUnsupportedPlatformType
 	}
@@ -163,18 +576,222 @@ func (p providerConfig) RawConfig() ([]byte, error) {
 	return rawConfig, nil
 }
 
+// providerConfigJSON is the serialised form of a providerConfig.
+type providerConfigJSON struct {
+	PlatformType configv1.PlatformType `json:"platformType"`
+	Config       json.RawMessage       `json:"config"`
+}
+
+// RawConfigIndent marshalls the configuration into a JSON byte slice indented with two spaces.
+// This is useful for golden files and logging where the output is read by a human.
+func (p providerConfig) RawConfigIndent() ([]byte, error) {
+	rawConfig, err := p.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.Buffer{}
+	if err := json.Indent(&out, rawConfig, "", "  "); err != nil {
+		return nil, fmt.Errorf("could not indent provider config: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// The output contains the platform type alongside the platform specific configuration
+// so that the ProviderConfig can be reconstructed using UnmarshalJSON.
+func (p providerConfig) MarshalJSON() ([]byte, error) {
+	rawConfig, err := p.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.Marshal(providerConfigJSON{
+		PlatformType: p.platformType,
+		Config:       rawConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal provider config: %w", err)
+	}
+
+	return out, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It reconstructs the platform specific configuration based on the serialised platform type.
+func (p *providerConfig) UnmarshalJSON(data []byte) error {
+	in := providerConfigJSON{}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("could not unmarshal provider config: %w", err)
+	}
+
+	out := providerConfig{
+		platformType: in.PlatformType,
+	}
+
+	switch in.PlatformType {
+	case configv1.AWSPlatformType:
+		if err := json.Unmarshal(in.Config, &out.aws.providerConfig); err != nil {
+			return fmt.Errorf("could not unmarshal provider spec: %w", err)
+		}
+	case configv1.GCPPlatformType:
+		if err := json.Unmarshal(in.Config, &out.gcp.providerConfig); err != nil {
+			return fmt.Errorf("could not unmarshal provider spec: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedPlatformType, in.PlatformType)
+	}
+
+	*p = out
+
+	return nil
+}
+
 // Type returns the platform type of the provider config.
 func (p providerConfig) Type() configv1.PlatformType {
 	return p.platformType
 }
 
+// Zone returns the zone configured within the provider config.
+func (p providerConfig) Zone() string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.providerConfig.Placement.AvailabilityZone
+	case configv1.GCPPlatformType:
+		return p.gcp.providerConfig.Zone
+	default:
+		return ""
+	}
+}
+
+// InstanceType returns the instance type of the provider config.
+// On GCP, this is the machine type.
+func (p providerConfig) InstanceType() string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.providerConfig.InstanceType
+	case configv1.GCPPlatformType:
+		return p.gcp.providerConfig.MachineType
+	default:
+		return ""
+	}
+}
+
+// Region returns the region of the provider config.
+// When an AWS provider config does not specify a region, the region is derived from the availability zone.
+func (p providerConfig) Region() string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.Region()
+	case configv1.GCPPlatformType:
+		return p.gcp.providerConfig.Region
+	default:
+		return ""
+	}
+}
+
+// SubnetString returns a human readable representation of the subnet of the provider config.
+// On GCP, this is the subnetwork of each network interface.
+func (p providerConfig) SubnetString() string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.subnetString()
+	case configv1.GCPPlatformType:
+		subnetworks := []string{}
+
+		for _, networkInterface := range p.gcp.providerConfig.NetworkInterfaces {
+			if networkInterface != nil && networkInterface.Subnetwork != "" {
+				subnetworks = append(subnetworks, networkInterface.Subnetwork)
+			}
+		}
+
+		return strings.Join(subnetworks, ",")
+	default:
+		return ""
+	}
+}
+
+// Tags returns the tags of the provider config.
+// GCP tags are network tags without values, so no tags are returned for GCP.
+func (p providerConfig) Tags() map[string]string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.Tags()
+	default:
+		return map[string]string{}
+	}
+}
+
+// SecurityGroups returns the security groups of the provider config.
+// GCP has no security groups, firewall rules are applied using network tags instead.
+func (p providerConfig) SecurityGroups() []string {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return p.aws.SecurityGroups()
+	default:
+		return nil
+	}
+}
+
+// SupportsFailureDomains returns true when the platform type has a failure domain concept.
+func (p providerConfig) SupportsFailureDomains() bool {
+	return platformSupportsFailureDomains(p.platformType)
+}
+
+// PlatformSupportsFailureDomains returns true when the platform reported by the platform status
+// of the Infrastructure has a failure domain concept.
+// Clusters without a platform status are assumed not to support failure domains.
+func PlatformSupportsFailureDomains(infra *configv1.Infrastructure) bool {
+	if infra == nil || infra.Status.PlatformStatus == nil {
+		return false
+	}
+
+	return platformSupportsFailureDomains(infra.Status.PlatformStatus.Type)
+}
+
+// platformSupportsFailureDomains returns true when the platform type has a failure domain concept.
+func platformSupportsFailureDomains(platformType configv1.PlatformType) bool {
+	switch platformType {
+	case configv1.AWSPlatformType, configv1.AzurePlatformType, configv1.GCPPlatformType, configv1.OpenStackPlatformType:
+		return true
+	default:
+		return false
+	}
+}
+
 // AWS returns the AWSProviderConfig if the platform type is AWS.
 func (p providerConfig) AWS() AWSProviderConfig {
 	return p.aws
 }
 
-// getPlatformTypeFromProviderSpecKind determines machine platform from providerSpec kind.
-func getPlatformTypeFromProviderSpecKind(kind string) (configv1.PlatformType, bool) {
+// AWSConfig returns the AWSProviderConfig, or an error if the platform type is not AWS.
+func (p providerConfig) AWSConfig() (AWSProviderConfig, error) {
+	if p.platformType != configv1.AWSPlatformType {
+		return AWSProviderConfig{}, fmt.Errorf("%w: cannot access %s provider config on platform %s", errMismatchedPlatformTypes, configv1.AWSPlatformType, p.platformType)
+	}
+
+	return p.aws, nil
+}
+
+// GCP returns the GCPProviderConfig if the platform type is GCP.
+func (p providerConfig) GCP() GCPProviderConfig {
+	return p.gcp
+}
+
+// GCPConfig returns the GCPProviderConfig, or an error if the platform type is not GCP.
+func (p providerConfig) GCPConfig() (GCPProviderConfig, error) {
+	if p.platformType != configv1.GCPPlatformType {
+		return GCPProviderConfig{}, fmt.Errorf("%w: cannot access %s provider config on platform %s", errMismatchedPlatformTypes, configv1.GCPPlatformType, p.platformType)
+	}
+
+	return p.gcp, nil
+}
+
+// PlatformForKind determines the machine platform from a providerSpec kind, for example
+// AWSMachineProviderConfig, without decoding the rest of the providerSpec.
+// The boolean is false when the kind is not recognised.
+func PlatformForKind(kind string) (configv1.PlatformType, bool) {
 	var providerSpecKindToPlatformType = map[string]configv1.PlatformType{
 		"AWSMachineProviderConfig":     configv1.AWSPlatformType,
 		"AzureMachineProviderSpec":     configv1.AzurePlatformType,
@@ -197,32 +814,44 @@ func getPlatformTypeFromMachineTemplate(tmpl machinev1.OpenShiftMachineV1Beta1Ma
 		return platformType, nil
 	}
 
-	return getPlatformTypeFromProviderSpec(tmpl.Spec.ProviderSpec)
+	return PlatformTypeFromProviderSpec(tmpl.Spec.ProviderSpec)
 }
 
-// getPlatformTypeFromProviderSpec determines machine platform from the providerSpec.
+// PlatformTypeFromProviderSpec determines machine platform from the providerSpec.
 // The providerSpec object's kind field is unmarshalled and the platform type is inferred from it.
-func getPlatformTypeFromProviderSpec(providerSpec machinev1beta1.ProviderSpec) (configv1.PlatformType, error) {
-	var platformType configv1.PlatformType
-	// Simple type for unmarshalling providerSpec kind.
-	type providerSpecKind struct {
-		metav1.TypeMeta `json:",inline"`
+func PlatformTypeFromProviderSpec(providerSpec machinev1beta1.ProviderSpec) (configv1.PlatformType, error) {
+	kind, err := providerSpecKind(providerSpec)
+	if err != nil {
+		return "", err
 	}
 
-	providerKind := providerSpecKind{}
-	if err := json.Unmarshal(providerSpec.Value.Raw, &providerKind); err != nil {
-		return "", fmt.Errorf("could not unmarshal provider spec: %w", err)
+	platformType, ok := PlatformForKind(kind)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errUnknownProviderConfigType, kind)
 	}
 
-	var ok bool
-	if platformType, ok = getPlatformTypeFromProviderSpecKind(providerKind.Kind); !ok {
-		return "", fmt.Errorf("%w: %s", errUnknownProviderConfigType, providerKind.Kind)
+	return platformType, nil
+}
+
+// providerSpecKind returns the kind set within the raw provider spec.
+func providerSpecKind(providerSpec machinev1beta1.ProviderSpec) (string, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(providerSpec.Value.Raw, &typeMeta); err != nil {
+		return "", fmt.Errorf("could not unmarshal provider spec: %w", err)
 	}
 
-	return platformType, nil
+	return typeMeta.Kind, nil
+}
+
+// IsUnsupportedPlatformError returns true when the error was caused by the provider spec
+// belonging to a platform that is not yet supported, rather than by the provider spec being invalid.
+func IsUnsupportedPlatformError(err error) bool {
+	return errors.Is(err, errUnsupportedPlatformType) || errors.Is(err, errUnknownProviderConfigType)
 }
 
 // ExtractFailureDomainsFromMachines creates list of FailureDomains extracted from the provided list of machines.
+// Each failure domain is only returned once, and the failure domains are sorted by their string representation
+// so that the output does not depend on the order of the machines.
 func ExtractFailureDomainsFromMachines(machines []machinev1beta1.Machine) ([]failuredomain.FailureDomain, error) {
 	machineFailureDomains := []failuredomain.FailureDomain{}
 
@@ -235,5 +864,127 @@ func ExtractFailureDomainsFromMachines(machines []machinev1beta1.Machine) ([]fai
 		machineFailureDomains = append(machineFailureDomains, providerconfig.ExtractFailureDomain())
 	}
 
+	machineFailureDomains = failuredomain.Distinct(machineFailureDomains)
+
+	sort.SliceStable(machineFailureDomains, func(i, j int) bool {
+		return machineFailureDomains[i].String() < machineFailureDomains[j].String()
+	})
+
 	return machineFailureDomains, nil
 }
+
+// ExtractFailureDomainsFromMachinesLenient creates a list of FailureDomains extracted from the provided list of machines.
+// Unlike ExtractFailureDomainsFromMachines, machines whose failure domain cannot be extracted are skipped,
+// and an error is returned for each of them, so that a single malformed machine does not hide the others.
+func ExtractFailureDomainsFromMachinesLenient(machines []machinev1beta1.Machine) ([]failuredomain.FailureDomain, []error) {
+	machineFailureDomains := []failuredomain.FailureDomain{}
+	errs := []error{}
+
+	for _, machine := range machines {
+		providerconfig, err := NewProviderConfigFromMachine(machine)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting failure domain from machine %s: %w", machine.Name, err))
+			continue
+		}
+
+		machineFailureDomains = append(machineFailureDomains, providerconfig.ExtractFailureDomain())
+	}
+
+	return machineFailureDomains, errs
+}
+
+// CountMachinesPerFailureDomain returns the number of machines using each failure domain,
+// keyed by the failure domain hash.
+// An error is returned if the failure domain of any machine cannot be determined, or
+// if the machines span multiple platforms.
+func CountMachinesPerFailureDomain(machines []machinev1beta1.Machine) (map[string]int, error) {
+	counts := map[string]int{}
+
+	var platformType configv1.PlatformType
+
+	for _, machine := range machines {
+		providerconfig, err := NewProviderConfigFromMachine(machine)
+		if err != nil {
+			return nil, fmt.Errorf("error getting failure domain from machine %s: %w", machine.Name, err)
+		}
+
+		fd := providerconfig.ExtractFailureDomain()
+
+		if platformType == "" {
+			platformType = fd.Type()
+		} else if fd.Type() != platformType {
+			return nil, fmt.Errorf("%w: machine %s is on platform %s, expected platform %s", errMismatchedPlatformTypes, machine.Name, fd.Type(), platformType)
+		}
+
+		counts[fd.Hash()]++
+	}
+
+	return counts, nil
+}
+
+// ValidateFailureDomainCoverage compares the declared failure domains with the failure domains
+// used by machines, as returned by ExtractFailureDomainsFromMachines.
+// It returns the declared failure domains that no machine is using, and the failure domains in use
+// by machines that have not been declared.
+func ValidateFailureDomainCoverage(declared, machineFailureDomains []failuredomain.FailureDomain) (missingFromMachines, missingFromDeclared []failuredomain.FailureDomain) {
+	return failuredomain.Difference(declared, machineFailureDomains), failuredomain.Difference(machineFailureDomains, declared)
+}
+
+// ExtractFailureDomainsFromMachineSets creates a list of FailureDomains extracted from the templates of the
+// provided list of machine sets.
+// Each failure domain is only returned once, even when it is used by multiple machine sets, and the failure
+// domains are sorted by their string representation, matching ExtractFailureDomainsFromMachines.
+func ExtractFailureDomainsFromMachineSets(machineSets []machinev1beta1.MachineSet) ([]failuredomain.FailureDomain, error) {
+	machineSetFailureDomains := []failuredomain.FailureDomain{}
+
+	for _, machineSet := range machineSets {
+		providerconfig, err := NewProviderConfigFromMachineSpec(machineSet.Spec.Template.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("error getting failure domain from machine set %s: %w", machineSet.Name, err)
+		}
+
+		machineSetFailureDomains = append(machineSetFailureDomains, providerconfig.ExtractFailureDomain())
+	}
+
+	machineSetFailureDomains = failuredomain.Distinct(machineSetFailureDomains)
+
+	sort.SliceStable(machineSetFailureDomains, func(i, j int) bool {
+		return machineSetFailureDomains[i].String() < machineSetFailureDomains[j].String()
+	})
+
+	return machineSetFailureDomains, nil
+}
+
+// ValidateFailureDomainConsistency inspects the provider configuration of the machines provided and returns
+// a warning for each machine which has no zone configured when other machines in the list do have a zone configured.
+// A warning is also returned for any machine whose provider configuration cannot be parsed.
+func ValidateFailureDomainConsistency(machines []machinev1beta1.Machine) []string {
+	warnings := []string{}
+	unzonedMachines := []string{}
+	zonedMachines := 0
+
+	for _, machine := range machines {
+		providerConfig, err := NewProviderConfigFromMachine(machine)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not determine failure domain for machine %s: %v", machine.Name, err))
+			continue
+		}
+
+		if providerConfig.Zone() == "" {
+			unzonedMachines = append(unzonedMachines, machine.Name)
+			continue
+		}
+
+		zonedMachines++
+	}
+
+	if zonedMachines == 0 {
+		return warnings
+	}
+
+	for _, machineName := range unzonedMachines {
+		warnings = append(warnings, fmt.Sprintf("machine %s has no zone configured but other control plane machines do", machineName))
+	}
+
+	return warnings
+}
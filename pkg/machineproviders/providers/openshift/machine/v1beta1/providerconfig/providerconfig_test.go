@@ -17,9 +17,12 @@ limitations under the License.
 package providerconfig
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
@@ -28,7 +31,10 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 // stringPtr returns a pointer to the string.
@@ -36,11 +42,22 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+// mustInjectFailureDomain injects the failure domain into a copy of the ProviderConfig and panics if
+// the failure domain cannot be injected, so that test inputs can be constructed inline.
+func mustInjectFailureDomain(pc ProviderConfig, fd failuredomain.FailureDomain) ProviderConfig {
+	injected, err := pc.InjectFailureDomain(fd)
+	if err != nil {
+		panic(fmt.Sprintf("could not inject failure domain %s into provider config: %v", fd, err))
+	}
+
+	return injected
+}
+
 var _ = Describe("Provider Config", func() {
 	Context("NewProviderConfigFromMachineTemplate", func() {
 		type providerConfigTableInput struct {
 			failureDomainsBuilder resourcebuilder.OpenShiftMachineV1Beta1FailureDomainsBuilder
-			modifyTemplate        func(tmpl *machinev1.ControlPlaneMachineSetTemplate)
+			platformType          configv1.PlatformType
 			providerSpecBuilder   resourcebuilder.RawExtensionBuilder
 			providerConfigMatcher types.GomegaMatcher
 			expectedPlatformType  configv1.PlatformType
@@ -50,14 +67,10 @@ var _ = Describe("Provider Config", func() {
 		DescribeTable("should extract the config", func(in providerConfigTableInput) {
 			tmpl := resourcebuilder.OpenShiftMachineV1Beta1Template().
 				WithFailureDomainsBuilder(in.failureDomainsBuilder).
+				WithPlatformType(in.platformType).
 				WithProviderSpecBuilder(in.providerSpecBuilder).
 				BuildTemplate()
 
-			if in.modifyTemplate != nil {
-				// Modify the template to allow injection of errors where the resource builder does not.
-				in.modifyTemplate(&tmpl)
-			}
-
 			providerConfig, err := NewProviderConfigFromMachineTemplate(*tmpl.OpenShiftMachineV1Beta1Machine)
 			if in.expectedError != nil {
 				Expect(err).To(MatchError(in.expectedError))
@@ -69,12 +82,16 @@ var _ = Describe("Provider Config", func() {
 			Expect(providerConfig).To(in.providerConfigMatcher)
 		},
 			Entry("with an invalid platform type", providerConfigTableInput{
-				modifyTemplate: func(in *machinev1.ControlPlaneMachineSetTemplate) {
-					// The platform type should be inferred from here first.
-					in.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform = configv1.PlatformType("invalid")
-				},
+				// The platform type should be inferred from the failure domains platform first.
+				platformType:  configv1.PlatformType("invalid"),
 				expectedError: fmt.Errorf("%w: %s", errUnsupportedPlatformType, "invalid"),
 			}),
+			Entry("with an invalid platform type overriding AWS failure domains", providerConfigTableInput{
+				failureDomainsBuilder: resourcebuilder.AWSFailureDomains(),
+				platformType:          configv1.PlatformType("invalid"),
+				providerSpecBuilder:   resourcebuilder.AWSProviderSpec(),
+				expectedError:         fmt.Errorf("%w: %s", errUnsupportedPlatformType, "invalid"),
+			}),
 			Entry("with an AWS config with failure domains", providerConfigTableInput{
 				expectedPlatformType:  configv1.AWSPlatformType,
 				failureDomainsBuilder: resourcebuilder.AWSFailureDomains(),
@@ -87,7 +104,26 @@ var _ = Describe("Provider Config", func() {
 				providerSpecBuilder:   resourcebuilder.AWSProviderSpec(),
 				providerConfigMatcher: HaveField("AWS().Config()", *resourcebuilder.AWSProviderSpec().Build()),
 			}),
+			Entry("with a GCP config with failure domains", providerConfigTableInput{
+				expectedPlatformType:  configv1.GCPPlatformType,
+				failureDomainsBuilder: resourcebuilder.GCPFailureDomains(),
+				providerSpecBuilder:   resourcebuilder.GCPProviderSpec(),
+				providerConfigMatcher: HaveField("GCP().Config()", *resourcebuilder.GCPProviderSpec().Build()),
+			}),
 		)
+
+		It("should infer the platform from Azure failure domains", func() {
+			tmpl := resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithFailureDomainsBuilder(resourcebuilder.AzureFailureDomains()).
+				WithProviderSpecBuilder(resourcebuilder.AzureProviderSpec()).
+				BuildTemplate()
+
+			Expect(tmpl.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform).To(Equal(configv1.AzurePlatformType))
+			Expect(tmpl.OpenShiftMachineV1Beta1Machine.FailureDomains.Azure).To(HaveValue(HaveLen(3)))
+
+			_, err := NewProviderConfigFromMachineTemplate(*tmpl.OpenShiftMachineV1Beta1Machine)
+			Expect(err).To(MatchError(fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType)))
+		})
 	})
 
 	Context("InjectFailureDomain", func() {
@@ -137,6 +173,136 @@ var _ = Describe("Provider Config", func() {
 				matchExpectation: "us-east-1b",
 			}),
 		)
+
+		It("should only change the zone and region of a GCP provider config", func() {
+			spec := resourcebuilder.GCPProviderSpec().WithRegion("").WithZone("us-central1-a").Build()
+			spec.OnHostMaintenance = machinev1beta1.TerminateHostMaintenanceType
+			spec.RestartPolicy = machinev1beta1.RestartPolicyNever
+			spec.GPUs = []machinev1beta1.GCPGPUConfig{{Count: 1, Type: "nvidia-tesla-t4"}}
+
+			pc := &providerConfig{
+				platformType: configv1.GCPPlatformType,
+				gcp: GCPProviderConfig{
+					providerConfig: *spec,
+				},
+			}
+
+			injected, err := pc.InjectFailureDomain(failuredomain.NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "europe-west1-b"}))
+			Expect(err).ToNot(HaveOccurred())
+
+			expected := *spec.DeepCopy()
+			expected.Zone = "europe-west1-b"
+			expected.Region = "europe-west1"
+
+			Expect(injected.GCP().Config()).To(Equal(expected))
+		})
+
+		It("should preserve the order of multiple GCP network interfaces", func() {
+			networkInterfaces := []*machinev1beta1.GCPNetworkInterface{
+				{
+					Network:    "gcp-network-primary",
+					Subnetwork: "gcp-subnetwork-primary",
+				},
+				{
+					Network:    "gcp-network-secondary",
+					Subnetwork: "gcp-subnetwork-secondary",
+					PublicIP:   true,
+				},
+			}
+
+			pc := &providerConfig{
+				platformType: configv1.GCPPlatformType,
+				gcp: GCPProviderConfig{
+					providerConfig: *resourcebuilder.GCPProviderSpec().WithNetworkInterfaces(networkInterfaces).Build(),
+				},
+			}
+
+			injected := mustInjectFailureDomain(pc, failuredomain.NewGCPFailureDomain(machinev1.GCPFailureDomain{Zone: "us-central1-b"}))
+
+			Expect(injected.GCP().Config().Zone).To(Equal("us-central1-b"))
+			Expect(injected.GCP().Config().NetworkInterfaces).To(Equal([]*machinev1beta1.GCPNetworkInterface{
+				{
+					Network:    "gcp-network-primary",
+					Subnetwork: "gcp-subnetwork-primary",
+				},
+				{
+					Network:    "gcp-network-secondary",
+					Subnetwork: "gcp-subnetwork-secondary",
+					PublicIP:   true,
+				},
+			}))
+		})
+	})
+
+	Context("mustInjectFailureDomain test helper", func() {
+		It("should inject the failure domain", func() {
+			pc := &providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+				},
+			}
+
+			injected := mustInjectFailureDomain(pc, failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build()))
+			Expect(injected.Zone()).To(Equal("us-east-1b"))
+		})
+
+		It("should panic when the failure domain cannot be injected", func() {
+			pc := &providerConfig{
+				platformType: configv1.BareMetalPlatformType,
+			}
+
+			Expect(func() {
+				mustInjectFailureDomain(pc, failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build()))
+			}).To(PanicWith("could not inject failure domain AWSFailureDomain{AvailabilityZone:us-east-1b} into provider config: unsupported platform type: BareMetal"))
+		})
+	})
+
+	Context("InjectFailureDomains", func() {
+		var baseConfig ProviderConfig
+
+		BeforeEach(func() {
+			baseConfig = &providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+				},
+			}
+		})
+
+		It("should return a provider config per failure domain", func() {
+			failureDomains := []failuredomain.FailureDomain{
+				failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").Build()),
+				failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").Build()),
+				failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").Build()),
+			}
+
+			configs, err := baseConfig.InjectFailureDomains(failureDomains)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(configs).To(HaveLen(3))
+			Expect(configs).To(HaveEach(HaveField("Type()", configv1.AWSPlatformType)))
+			Expect(configs[0]).To(HaveField("AWS().Config().Placement.AvailabilityZone", "us-east-1a"))
+			Expect(configs[1]).To(HaveField("AWS().Config().Placement.AvailabilityZone", "us-east-1b"))
+			Expect(configs[2]).To(HaveField("AWS().Config().Placement.AvailabilityZone", "us-east-1c"))
+
+			By("Not modifying the base config")
+			Expect(baseConfig).To(HaveField("AWS().Config().Placement.AvailabilityZone", "us-east-1a"))
+		})
+
+		It("should return an error identifying the failure domain that could not be injected", func() {
+			baseConfig = &providerConfig{
+				platformType: configv1.AzurePlatformType,
+			}
+
+			failureDomains := []failuredomain.FailureDomain{
+				failuredomain.NewAzureFailureDomain(resourcebuilder.AzureFailureDomain().WithZone("1").Build()),
+			}
+
+			configs, err := baseConfig.InjectFailureDomains(failureDomains)
+			Expect(err).To(MatchError(fmt.Errorf("could not inject failure domain at index 0: %w", fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType))))
+			Expect(configs).To(BeNil())
+		})
 	})
 
 	Context("NewProviderConfigFromMachine", func() {
@@ -183,167 +349,1754 @@ var _ = Describe("Provider Config", func() {
 				providerSpecBuilder:   resourcebuilder.AWSProviderSpec(),
 				providerConfigMatcher: HaveField("AWS().Config()", *resourcebuilder.AWSProviderSpec().Build()),
 			}),
+			Entry("with a GCP config", providerConfigTableInput{
+				expectedPlatformType:  configv1.GCPPlatformType,
+				providerSpecBuilder:   resourcebuilder.GCPProviderSpec(),
+				providerConfigMatcher: HaveField("GCP().Config()", *resourcebuilder.GCPProviderSpec().Build()),
+			}),
 		)
+
+		It("should extract the config from a failed master machine", func() {
+			machine := resourcebuilder.Machine().AsMaster().WithName("master-0").
+				WithProviderID("aws:///us-east-1a/i-12345678").
+				WithErrorReason(machinev1beta1.InvalidConfigurationMachineError).
+				WithErrorMessage("could not create instance").
+				WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).
+				Build()
+
+			Expect(machine.Spec.ProviderID).To(HaveValue(Equal("aws:///us-east-1a/i-12345678")))
+			Expect(machine.Status.ErrorReason).To(HaveValue(Equal(machinev1beta1.InvalidConfigurationMachineError)))
+			Expect(machine.Status.ErrorMessage).To(HaveValue(Equal("could not create instance")))
+
+			providerConfig, err := NewProviderConfigFromMachine(*machine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(providerConfig).To(HaveField("AWS().Config()", *resourcebuilder.AWSProviderSpec().Build()))
+		})
 	})
 
-	Context("ExtractFailureDomainsFromMachines", func() {
+	Context("NewProviderConfigFromMachineWithContext", func() {
+		It("should extract the config with a valid context", func() {
+			machine := resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).Build()
 
-		type extractFailureDomainsFromMachinesTableInput struct {
-			machines               []machinev1beta1.Machine
-			expectedError          error
-			expectedFailureDomains []failuredomain.FailureDomain
-		}
+			providerConfig, err := NewProviderConfigFromMachineWithContext(context.Background(), *machine)
+			Expect(err).ToNot(HaveOccurred())
 
-		awsSubnet := machinev1.AWSResourceReference{
-			Type: machinev1.AWSFiltersReferenceType,
-			Filters: &[]machinev1.AWSResourceFilter{
-				{
-					Name: "tag:Name",
-					Values: []string{
-						"aws-subnet-12345678",
+			Expect(providerConfig.Type()).To(Equal(configv1.AWSPlatformType))
+			Expect(providerConfig).To(HaveField("AWS().Config()", *resourcebuilder.AWSProviderSpec().Build()))
+		})
+
+		It("should return an error when the context is cancelled", func() {
+			machine := resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).Build()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := NewProviderConfigFromMachineWithContext(ctx, *machine)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(err).To(MatchError("could not create provider config for machine master-0: context canceled"))
+		})
+
+		It("should log the detected kind, platform and failure domain", func() {
+			machine := resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).Build()
+			logger := test.NewTestLogger()
+
+			_, err := NewProviderConfigFromMachineWithContext(logr.NewContext(context.Background(), logger.Logger()), *machine)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logger.Entries()).To(ConsistOf(test.LogEntry{
+				Level:   4,
+				Message: "Parsed provider config",
+				KeysAndValues: []interface{}{
+					"machineName", "master-0",
+					"kind", "AWSMachineProviderConfig",
+					"platform", configv1.AWSPlatformType,
+					"hasFailureDomain", true,
+				},
+			}))
+		})
+
+		It("should log that a subnet only AWS failure domain has a failure domain", func() {
+			machine := resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("")).Build()
+			logger := test.NewTestLogger()
+
+			_, err := NewProviderConfigFromMachineWithContext(logr.NewContext(context.Background(), logger.Logger()), *machine)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(logger.Entries()).To(ConsistOf(HaveField("KeysAndValues", ContainElements("hasFailureDomain", true))))
+		})
+
+		It("should log the detected kind and platform when the provider config cannot be parsed", func() {
+			machine := resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).Build()
+			machine.Spec.ProviderSpec.Value.Raw = []byte(`{"kind":"AzureMachineProviderSpec"}`)
+			logger := test.NewTestLogger()
+
+			_, err := NewProviderConfigFromMachineWithContext(logr.NewContext(context.Background(), logger.Logger()), *machine)
+			Expect(err).To(MatchError(ContainSubstring("unsupported platform type: Azure")))
+
+			Expect(logger.Entries()).To(ConsistOf(test.LogEntry{
+				Level:   4,
+				Message: "Could not parse provider config",
+				KeysAndValues: []interface{}{
+					"machineName", "master-0",
+					"kind", "AzureMachineProviderSpec",
+					"platform", configv1.AzurePlatformType,
+					"error", "unsupported platform type: Azure",
+				},
+			}))
+		})
+	})
+
+	Context("NewProviderConfigFromMachineSpec", func() {
+		It("should extract the config from an AWS machine spec", func() {
+			machine := resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec()).Build()
+
+			providerConfig, err := NewProviderConfigFromMachineSpec(machine.Spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(providerConfig.Type()).To(Equal(configv1.AWSPlatformType))
+			Expect(providerConfig).To(HaveField("AWS().Config()", *resourcebuilder.AWSProviderSpec().Build()))
+		})
+
+		It("should return an error when the provider spec kind is unknown", func() {
+			spec := machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte(`{"kind":"InvalidProviderSpecKind"}`),
 					},
 				},
-			},
-		}
+			}
 
-		DescribeTable("should correctly extract the failure domains", func(in extractFailureDomainsFromMachinesTableInput) {
-			failureDomains, err := ExtractFailureDomainsFromMachines(in.machines)
+			_, err := NewProviderConfigFromMachineSpec(spec)
+			Expect(err).To(MatchError(fmt.Errorf("could not determine platform type: %w", fmt.Errorf("%w: %s", errUnknownProviderConfigType, "InvalidProviderSpecKind"))))
+		})
+	})
 
-			if in.expectedError != nil {
-				Expect(err).To(Equal(MatchError(in.expectedError)))
+	Context("SupportedPlatforms", func() {
+		It("should include the platforms with a provider config implementation", func() {
+			Expect(SupportedPlatforms()).To(ContainElements(configv1.AWSPlatformType, configv1.GCPPlatformType))
+		})
+
+		It("should not include unsupported platforms", func() {
+			Expect(SupportedPlatforms()).ToNot(ContainElement(configv1.NonePlatformType))
+		})
+
+		It("should only include platforms that can construct a provider config", func() {
+			for _, platform := range SupportedPlatforms() {
+				_, err := newProviderConfigFromProviderSpec(machinev1beta1.ProviderSpec{Value: &runtime.RawExtension{Raw: []byte("{}")}}, platform)
+				Expect(IsUnsupportedPlatformError(err)).To(BeFalse(), "platform %s should be supported", platform)
 			}
+		})
 
-			Expect(failureDomains).To(Equal(in.expectedFailureDomains))
+		Context("each supported platform", func() {
+			// providerSpecBuilders holds a valid provider spec for each supported platform.
+			// New platforms must add a builder here so that their implementation is exercised.
+			providerSpecBuilders := map[configv1.PlatformType]resourcebuilder.RawExtensionBuilder{
+				configv1.AWSPlatformType: resourcebuilder.AWSProviderSpec(),
+				configv1.GCPPlatformType: resourcebuilder.GCPProviderSpec(),
+			}
+
+			It("should have a provider spec builder for every supported platform", func() {
+				for _, platform := range SupportedPlatforms() {
+					Expect(providerSpecBuilders).To(HaveKey(platform), "platform %s is registered but has no provider spec builder in this test", platform)
+				}
+			})
+
+			It("should implement the provider config and failure domain methods", func() {
+				for _, platform := range SupportedPlatforms() {
+					builder, ok := providerSpecBuilders[platform]
+					if !ok {
+						// Reported by the test above.
+						continue
+					}
+
+					By(fmt.Sprintf("Checking the %s implementation", platform))
+					providerConfig, err := newProviderConfigFromProviderSpec(machinev1beta1.ProviderSpec{Value: builder.BuildRawExtension()}, platform)
+					Expect(err).ToNot(HaveOccurred(), "platform %s should construct a provider config", platform)
+
+					Expect(providerConfig.Type()).To(Equal(platform), "platform %s should implement Type", platform)
+
+					raw, err := providerConfig.RawConfig()
+					Expect(err).ToNot(HaveOccurred(), "platform %s should implement RawConfig", platform)
+					Expect(raw).ToNot(BeEmpty(), "platform %s should implement RawConfig", platform)
+
+					equal, err := providerConfig.Equal(providerConfig)
+					Expect(err).ToNot(HaveOccurred(), "platform %s should implement Equal", platform)
+					Expect(equal).To(BeTrue(), "platform %s should implement Equal", platform)
+
+					Expect(providerConfig.Validate()).To(BeEmpty(), "platform %s should implement Validate", platform)
+
+					fd := providerConfig.ExtractFailureDomain()
+					Expect(fd).ToNot(BeNil(), "platform %s should implement ExtractFailureDomain", platform)
+					Expect(fd.Type()).To(Equal(platform), "platform %s should construct a failure domain for its own platform", platform)
+					Expect(fd.Equal(fd)).To(BeTrue(), "platform %s should implement failure domain Equal", platform)
+					Expect(fd.String()).ToNot(Equal("<unknown>"), "platform %s should implement failure domain String", platform)
+
+					injected, err := providerConfig.InjectFailureDomain(fd)
+					Expect(err).ToNot(HaveOccurred(), "platform %s should implement InjectFailureDomain", platform)
+					Expect(injected.Equal(providerConfig)).To(BeTrue(), "platform %s should round trip its own failure domain", platform)
+				}
+			})
+
+			// failureDomains holds a failure domain for each supported platform that differs from
+			// the failure domain configured by the default provider spec builder for that platform.
+			failureDomains := map[configv1.PlatformType]failuredomain.FailureDomain{
+				configv1.AWSPlatformType: failuredomain.NewAWSFailureDomain(
+					resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(machinev1.AWSResourceReference{
+						Type: machinev1.AWSFiltersReferenceType,
+						Filters: &[]machinev1.AWSResourceFilter{
+							{
+								Name:   "tag:Name",
+								Values: []string{"subnet-us-east-1b"},
+							},
+						},
+					}).Build(),
+				),
+				configv1.GCPPlatformType: failuredomain.NewGCPFailureDomain(
+					resourcebuilder.GCPFailureDomain().WithZone("us-central1-b").Build(),
+				),
+			}
+
+			It("should inject failure domains without modifying the original provider config", func() {
+				for _, platform := range SupportedPlatforms() {
+					builder, builderOK := providerSpecBuilders[platform]
+					fd, fdOK := failureDomains[platform]
+					Expect(fdOK).To(BeTrue(), "platform %s is registered but has no failure domain in this test", platform)
+
+					if !builderOK || !fdOK {
+						continue
+					}
+
+					By(fmt.Sprintf("Injecting a failure domain into the %s implementation", platform))
+					providerConfig, err := newProviderConfigFromProviderSpec(machinev1beta1.ProviderSpec{Value: builder.BuildRawExtension()}, platform)
+					Expect(err).ToNot(HaveOccurred(), "platform %s should construct a provider config", platform)
+
+					before, err := providerConfig.RawConfig()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(providerConfig.ExtractFailureDomain().Equal(fd)).To(BeFalse(), "the failure domain for platform %s should differ from the base provider config", platform)
+
+					injected, err := providerConfig.InjectFailureDomain(fd)
+					Expect(err).ToNot(HaveOccurred(), "platform %s should implement InjectFailureDomain", platform)
+					Expect(injected.ExtractFailureDomain().Equal(fd)).To(BeTrue(), "platform %s should reflect the injected failure domain", platform)
+
+					after, err := providerConfig.RawConfig()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(after).To(Equal(before), "platform %s should not modify the original provider config when injecting a failure domain", platform)
+				}
+			})
+		})
+	})
+
+	Context("IsUnsupportedPlatformError", func() {
+		type isUnsupportedPlatformErrorTableInput struct {
+			err      error
+			expected bool
+		}
+
+		DescribeTable("should detect unsupported platform errors", func(in isUnsupportedPlatformErrorTableInput) {
+			Expect(IsUnsupportedPlatformError(in.err)).To(Equal(in.expected))
 		},
-			Entry("when there are no machines", extractFailureDomainsFromMachinesTableInput{
-				machines:               []machinev1beta1.Machine{},
-				expectedError:          nil,
-				expectedFailureDomains: []failuredomain.FailureDomain{},
+			Entry("with an unsupported platform type", isUnsupportedPlatformErrorTableInput{
+				err:      fmt.Errorf("could not create provider config: %w", fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType)),
+				expected: true,
 			}),
-			Entry("with machines", extractFailureDomainsFromMachinesTableInput{
-				machines: []machinev1beta1.Machine{
-					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
-					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
-					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
-				},
-				expectedError: nil,
-				expectedFailureDomains: []failuredomain.FailureDomain{
-					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
-					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
-					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
-				},
+			Entry("with an unknown provider config type", isUnsupportedPlatformErrorTableInput{
+				err:      fmt.Errorf("could not determine platform type: %w", fmt.Errorf("%w: %s", errUnknownProviderConfigType, "VSphereMachineProviderSpec")),
+				expected: true,
+			}),
+			Entry("with a decoding error", isUnsupportedPlatformErrorTableInput{
+				err:      fmt.Errorf("could not unmarshal provider spec: %w", errors.New("unexpected end of JSON input")),
+				expected: false,
 			}),
 		)
-
 	})
-	Context("ExtractFailureDomain", func() {
-		type extractFailureDomainTableInput struct {
-			providerConfig        ProviderConfig
-			expectedFailureDomain failuredomain.FailureDomain
-		}
-		filterSubnet := machinev1.AWSResourceReference{
-			Type: machinev1.AWSFiltersReferenceType,
-			Filters: &[]machinev1.AWSResourceFilter{{
-				Name:   "tag:Name",
-				Values: []string{"aws-subnet-12345678"},
-			}},
+
+	Context("PlatformForKind", func() {
+		type platformForKindTableInput struct {
+			kind             string
+			expectedPlatform configv1.PlatformType
+			expectedOK       bool
 		}
 
-		DescribeTable("should correctly extract the failure domain", func(in extractFailureDomainTableInput) {
-			fd := in.providerConfig.ExtractFailureDomain()
+		DescribeTable("should map the provider spec kind to a platform", func(in platformForKindTableInput) {
+			platform, ok := PlatformForKind(in.kind)
+			Expect(ok).To(Equal(in.expectedOK))
+			Expect(platform).To(Equal(in.expectedPlatform))
+		},
+			Entry("with an AWS provider spec kind", platformForKindTableInput{
+				kind:             "AWSMachineProviderConfig",
+				expectedPlatform: configv1.AWSPlatformType,
+				expectedOK:       true,
+			}),
+			Entry("with a GCP provider spec kind", platformForKindTableInput{
+				kind:             "GCPMachineProviderSpec",
+				expectedPlatform: configv1.GCPPlatformType,
+				expectedOK:       true,
+			}),
+			Entry("with an unknown provider spec kind", platformForKindTableInput{
+				kind:             "VSphereMachineProviderSpec",
+				expectedPlatform: "",
+				expectedOK:       false,
+			}),
+		)
+	})
 
-			Expect(fd).To(Equal(in.expectedFailureDomain))
+	Context("Zone", func() {
+		type zoneTableInput struct {
+			providerConfig ProviderConfig
+			expectedZone   string
+		}
+
+		DescribeTable("should return the zone of the provider config", func(in zoneTableInput) {
+			Expect(in.providerConfig.Zone()).To(Equal(in.expectedZone))
 		},
-			Entry("with an AWS us-east-1a failure domain", extractFailureDomainTableInput{
+			Entry("with an AWS config", zoneTableInput{
 				providerConfig: &providerConfig{
 					platformType: configv1.AWSPlatformType,
 					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(convertAWSResourceReferenceV1ToV1Beta1(&filterSubnet)).Build(),
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").Build(),
 					},
 				},
-				expectedFailureDomain: failuredomain.NewAWSFailureDomain(
-					resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build(),
-				),
+				expectedZone: "us-east-1b",
 			}),
-			Entry("with an AWS us-east-1b failure domain", extractFailureDomainTableInput{
+			Entry("with a GCP config", zoneTableInput{
 				providerConfig: &providerConfig{
-					platformType: configv1.AWSPlatformType,
-					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").WithSubnet(convertAWSResourceReferenceV1ToV1Beta1(&filterSubnet)).Build(),
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().WithZone("us-central1-c").Build(),
 					},
 				},
-				expectedFailureDomain: failuredomain.NewAWSFailureDomain(
-					resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet).Build(),
-				),
+				expectedZone: "us-central1-c",
+			}),
+			Entry("with a BareMetal config", zoneTableInput{
+				providerConfig: &providerConfig{platformType: configv1.BareMetalPlatformType},
+				expectedZone:   "",
 			}),
 		)
 	})
 
-	Context("Equal", func() {
-		type equalTableInput struct {
-			basePC        ProviderConfig
-			comparePC     ProviderConfig
-			expectedEqual bool
-			expectedError error
+	Context("InstanceType", func() {
+		type instanceTypeTableInput struct {
+			providerConfig       ProviderConfig
+			expectedInstanceType string
 		}
 
-		DescribeTable("should compare provider configs", func(in equalTableInput) {
-			equal, err := in.basePC.Equal(in.comparePC)
-
-			if in.expectedError != nil {
-				Expect(err).To(MatchError(in.expectedError))
-			} else {
-				Expect(err).ToNot(HaveOccurred())
-			}
-
-			Expect(equal).To(Equal(in.expectedEqual), "Equality of provider configs was not as expected")
+		DescribeTable("should return the instance type of the provider config", func(in instanceTypeTableInput) {
+			Expect(in.providerConfig.InstanceType()).To(Equal(in.expectedInstanceType))
 		},
-			Entry("with different platform types", equalTableInput{
-				basePC: &providerConfig{
+			Entry("with an AWS config", instanceTypeTableInput{
+				providerConfig: &providerConfig{
 					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithInstanceType("m5.2xlarge").Build(),
+					},
 				},
-				comparePC: &providerConfig{
-					platformType: configv1.AzurePlatformType,
+				expectedInstanceType: "m5.2xlarge",
+			}),
+			Entry("with a GCP config", instanceTypeTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().Build(),
+					},
 				},
-				expectedEqual: false,
-				expectedError: errMismatchedPlatformTypes,
+				expectedInstanceType: "n1-standard-4",
 			}),
-			Entry("with matching AWS configs", equalTableInput{
-				basePC: &providerConfig{
+			Entry("with a BareMetal config", instanceTypeTableInput{
+				providerConfig:       &providerConfig{platformType: configv1.BareMetalPlatformType},
+				expectedInstanceType: "",
+			}),
+		)
+	})
+
+	Context("Region", func() {
+		type regionTableInput struct {
+			providerConfig ProviderConfig
+			expectedRegion string
+		}
+
+		DescribeTable("should return the region of the provider config", func(in regionTableInput) {
+			Expect(in.providerConfig.Region()).To(Equal(in.expectedRegion))
+		},
+			Entry("with an AWS config with a region", regionTableInput{
+				providerConfig: &providerConfig{
 					platformType: configv1.AWSPlatformType,
 					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithRegion("us-east-1").WithAvailabilityZone("us-east-1b").Build(),
 					},
 				},
-				comparePC: &providerConfig{
+				expectedRegion: "us-east-1",
+			}),
+			Entry("with an AWS config without a region", regionTableInput{
+				providerConfig: &providerConfig{
 					platformType: configv1.AWSPlatformType,
 					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithRegion("").WithAvailabilityZone("us-east-1a").Build(),
 					},
 				},
-				expectedEqual: true,
+				expectedRegion: "us-east-1",
 			}),
-			Entry("with mis-matched AWS configs", equalTableInput{
-				basePC: &providerConfig{
+			Entry("with an AWS config without a region or availability zone", regionTableInput{
+				providerConfig: &providerConfig{
 					platformType: configv1.AWSPlatformType,
 					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithRegion("").WithAvailabilityZone("").Build(),
 					},
 				},
-				comparePC: &providerConfig{
-					platformType: configv1.AWSPlatformType,
-					aws: AWSProviderConfig{
-						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").Build(),
+				expectedRegion: "",
+			}),
+			Entry("with a GCP config", regionTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().WithRegion("us-central1").Build(),
 					},
 				},
-				expectedEqual: false,
+				expectedRegion: "us-central1",
+			}),
+			Entry("with a BareMetal config", regionTableInput{
+				providerConfig: &providerConfig{platformType: configv1.BareMetalPlatformType},
+				expectedRegion: "",
 			}),
 		)
 	})
 
-	Context("RawConfig", func() {
-		type rawConfigTableInput struct {
-			providerConfig ProviderConfig
+	Context("Subnet", func() {
+		filterSubnet := machinev1beta1.AWSResourceReference{
+			Filters: []machinev1beta1.Filter{
+				{
+					Name:   "tag:Name",
+					Values: []string{"aws-subnet-12345678"},
+				},
+			},
+		}
+
+		It("should return the subnet of an AWS config", func() {
+			awsConfig := AWSProviderConfig{
+				providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(filterSubnet).Build(),
+			}
+
+			Expect(awsConfig.Subnet()).To(Equal(filterSubnet))
+		})
+
+		type subnetStringTableInput struct {
+			providerConfig ProviderConfig
+			expectedSubnet string
+		}
+
+		DescribeTable("should return a string representation of the subnet of the provider config", func(in subnetStringTableInput) {
+			Expect(in.providerConfig.SubnetString()).To(Equal(in.expectedSubnet))
+		},
+			Entry("with an AWS config with a filter subnet", subnetStringTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(filterSubnet).Build(),
+					},
+				},
+				expectedSubnet: "{Type:filters, Value:[{Name:tag:Name Values:[aws-subnet-12345678]}]}",
+			}),
+			Entry("with an AWS config with an ID subnet", subnetStringTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(machinev1beta1.AWSResourceReference{
+							ID: stringPtr("subnet-12345678"),
+						}).Build(),
+					},
+				},
+				expectedSubnet: "{Type:id, Value:subnet-12345678}",
+			}),
+			Entry("with an AWS config without a subnet", subnetStringTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(machinev1beta1.AWSResourceReference{}).Build(),
+					},
+				},
+				expectedSubnet: "",
+			}),
+			Entry("with a GCP config", subnetStringTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().Build(),
+					},
+				},
+				expectedSubnet: "gcp-subnetwork-12345678",
+			}),
+			Entry("with a BareMetal config", subnetStringTableInput{
+				providerConfig: &providerConfig{platformType: configv1.BareMetalPlatformType},
+				expectedSubnet: "",
+			}),
+		)
+	})
+
+	Context("Tags", func() {
+		type tagsTableInput struct {
+			providerConfig ProviderConfig
+			expectedTags   map[string]string
+		}
+
+		DescribeTable("should return the tags of the provider config", func(in tagsTableInput) {
+			Expect(in.providerConfig.Tags()).To(Equal(in.expectedTags))
+		},
+			Entry("with an AWS config with tags", tagsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithTags([]machinev1beta1.TagSpecification{
+							{Name: "owner", Value: "team-a"},
+							{Name: "environment", Value: "production"},
+						}).Build(),
+					},
+				},
+				expectedTags: map[string]string{
+					"owner":       "team-a",
+					"environment": "production",
+				},
+			}),
+			Entry("with an AWS config without tags", tagsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+					},
+				},
+				expectedTags: map[string]string{},
+			}),
+			Entry("with a GCP config", tagsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().Build(),
+					},
+				},
+				expectedTags: map[string]string{},
+			}),
+		)
+	})
+
+	Context("SecurityGroups", func() {
+		type securityGroupsTableInput struct {
+			providerConfig         ProviderConfig
+			expectedSecurityGroups []string
+		}
+
+		DescribeTable("should return the security groups of the provider config", func(in securityGroupsTableInput) {
+			Expect(in.providerConfig.SecurityGroups()).To(Equal(in.expectedSecurityGroups))
+		},
+			Entry("with an AWS config with filter security groups", securityGroupsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSecurityGroups([]machinev1beta1.AWSResourceReference{
+							{
+								Filters: []machinev1beta1.Filter{
+									{
+										Name:   "tag:Name",
+										Values: []string{"cluster-master-sg", "cluster-lb-sg"},
+									},
+								},
+							},
+						}).Build(),
+					},
+				},
+				expectedSecurityGroups: []string{"cluster-master-sg", "cluster-lb-sg"},
+			}),
+			Entry("with an AWS config with ID and ARN security groups", securityGroupsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSecurityGroups([]machinev1beta1.AWSResourceReference{
+							{ID: stringPtr("sg-12345678")},
+							{ARN: stringPtr("arn:aws:ec2:us-east-1:123456789012:security-group/sg-87654321")},
+						}).Build(),
+					},
+				},
+				expectedSecurityGroups: []string{"sg-12345678", "arn:aws:ec2:us-east-1:123456789012:security-group/sg-87654321"},
+			}),
+			Entry("with an AWS config without security groups", securityGroupsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSecurityGroups(nil).Build(),
+					},
+				},
+				expectedSecurityGroups: nil,
+			}),
+			Entry("with a GCP config", securityGroupsTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().Build(),
+					},
+				},
+				expectedSecurityGroups: nil,
+			}),
+		)
+	})
+
+	Context("SupportsFailureDomains", func() {
+		type supportsFailureDomainsTableInput struct {
+			providerConfig ProviderConfig
+			expected       bool
+		}
+
+		DescribeTable("should report whether the platform uses failure domains", func(in supportsFailureDomainsTableInput) {
+			Expect(in.providerConfig.SupportsFailureDomains()).To(Equal(in.expected))
+		},
+			Entry("with an AWS config", supportsFailureDomainsTableInput{
+				providerConfig: &providerConfig{platformType: configv1.AWSPlatformType},
+				expected:       true,
+			}),
+			Entry("with an Azure config", supportsFailureDomainsTableInput{
+				providerConfig: &providerConfig{platformType: configv1.AzurePlatformType},
+				expected:       true,
+			}),
+			Entry("with a GCP config", supportsFailureDomainsTableInput{
+				providerConfig: &providerConfig{platformType: configv1.GCPPlatformType},
+				expected:       true,
+			}),
+			Entry("with a BareMetal config", supportsFailureDomainsTableInput{
+				providerConfig: &providerConfig{platformType: configv1.BareMetalPlatformType},
+				expected:       false,
+			}),
+			Entry("with a Nutanix config", supportsFailureDomainsTableInput{
+				providerConfig: &providerConfig{platformType: configv1.NutanixPlatformType},
+				expected:       false,
+			}),
+		)
+	})
+
+	Context("PlatformSupportsFailureDomains", func() {
+		type platformSupportsFailureDomainsTableInput struct {
+			infrastructure *configv1.Infrastructure
+			expected       bool
+		}
+
+		DescribeTable("should report whether the cluster platform uses failure domains", func(in platformSupportsFailureDomainsTableInput) {
+			Expect(PlatformSupportsFailureDomains(in.infrastructure)).To(Equal(in.expected))
+		},
+			Entry("with an AWS infrastructure", platformSupportsFailureDomainsTableInput{
+				infrastructure: resourcebuilder.Infrastructure().WithPlatformType(configv1.AWSPlatformType).Build(),
+				expected:       true,
+			}),
+			Entry("with a BareMetal infrastructure", platformSupportsFailureDomainsTableInput{
+				infrastructure: resourcebuilder.Infrastructure().WithPlatformType(configv1.BareMetalPlatformType).Build(),
+				expected:       false,
+			}),
+			Entry("with an infrastructure without a platform status", platformSupportsFailureDomainsTableInput{
+				infrastructure: resourcebuilder.Infrastructure().Build(),
+				expected:       false,
+			}),
+			Entry("with a nil infrastructure", platformSupportsFailureDomainsTableInput{
+				infrastructure: nil,
+				expected:       false,
+			}),
+		)
+	})
+
+	Context("platform accessors", func() {
+		awsProviderSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")
+		gcpProviderSpec := resourcebuilder.GCPProviderSpec().WithZone("us-central1-a")
+
+		It("should return the AWS config on AWS", func() {
+			providerConfig, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: awsProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			awsConfig, err := providerConfig.AWSConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsConfig.Config().Placement.AvailabilityZone).To(Equal("us-east-1a"))
+		})
+
+		It("should return an error when accessing the GCP config on AWS", func() {
+			providerConfig, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: awsProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = providerConfig.GCPConfig()
+			Expect(err).To(MatchError(errMismatchedPlatformTypes))
+			Expect(err).To(MatchError(ContainSubstring("cannot access GCP provider config on platform AWS")))
+		})
+
+		It("should return the GCP config on GCP", func() {
+			providerConfig, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: gcpProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			gcpConfig, err := providerConfig.GCPConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gcpConfig.Config().Zone).To(Equal("us-central1-a"))
+		})
+
+		It("should return an error when accessing the AWS config on GCP", func() {
+			providerConfig, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: gcpProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = providerConfig.AWSConfig()
+			Expect(err).To(MatchError(errMismatchedPlatformTypes))
+			Expect(err).To(MatchError(ContainSubstring("cannot access AWS provider config on platform GCP")))
+		})
+	})
+
+	Context("ExtractFailureDomainsFromMachines", func() {
+
+		type extractFailureDomainsFromMachinesTableInput struct {
+			machines               []machinev1beta1.Machine
+			expectedError          error
+			expectedFailureDomains []failuredomain.FailureDomain
+		}
+
+		awsSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		}
+
+		DescribeTable("should correctly extract the failure domains", func(in extractFailureDomainsFromMachinesTableInput) {
+			failureDomains, err := ExtractFailureDomainsFromMachines(in.machines)
+
+			if in.expectedError != nil {
+				Expect(err).To(Equal(MatchError(in.expectedError)))
+			}
+
+			Expect(failureDomains).To(Equal(in.expectedFailureDomains))
+		},
+			Entry("when there are no machines", extractFailureDomainsFromMachinesTableInput{
+				machines:               []machinev1beta1.Machine{},
+				expectedError:          nil,
+				expectedFailureDomains: []failuredomain.FailureDomain{},
+			}),
+			Entry("with machines", extractFailureDomainsFromMachinesTableInput{
+				machines: []machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+				},
+				expectedError: nil,
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
+				},
+			}),
+			Entry("with unsorted machines sharing failure domains", extractFailureDomainsFromMachinesTableInput{
+				machines: []machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+					*resourcebuilder.Machine().WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+				},
+				expectedError: nil,
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
+				},
+			}),
+		)
+
+	})
+
+	Context("ExtractFailureDomainsFromMachinesLenient", func() {
+		awsSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		}
+
+		It("should skip and record machines that cannot be parsed", func() {
+			malformed := resourcebuilder.Machine().WithName("master-2").Build()
+			malformed.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: []byte("{")}
+
+			machines := []machinev1beta1.Machine{
+				*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+				*resourcebuilder.Machine().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+				*malformed,
+			}
+
+			failureDomains, errs := ExtractFailureDomainsFromMachinesLenient(machines)
+
+			Expect(failureDomains).To(Equal([]failuredomain.FailureDomain{
+				failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+				failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+			}))
+			Expect(errs).To(ConsistOf(MatchError(ContainSubstring("error getting failure domain from machine master-2"))))
+		})
+
+		It("should not return any errors when all machines can be parsed", func() {
+			machines := []machinev1beta1.Machine{
+				*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+			}
+
+			failureDomains, errs := ExtractFailureDomainsFromMachinesLenient(machines)
+
+			Expect(failureDomains).To(HaveLen(1))
+			Expect(errs).To(BeEmpty())
+		})
+	})
+
+	Context("ExtractFailureDomainsFromMachineSets", func() {
+		type extractFailureDomainsFromMachineSetsTableInput struct {
+			machineSets            []machinev1beta1.MachineSet
+			expectedError          error
+			expectedFailureDomains []failuredomain.FailureDomain
+		}
+
+		awsSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		}
+
+		machineSetBuilder := resourcebuilder.MachineSet().AsWorker()
+
+		DescribeTable("should correctly extract the failure domains", func(in extractFailureDomainsFromMachineSetsTableInput) {
+			failureDomains, err := ExtractFailureDomainsFromMachineSets(in.machineSets)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(failureDomains).To(Equal(in.expectedFailureDomains))
+		},
+			Entry("when there are no machine sets", extractFailureDomainsFromMachineSetsTableInput{
+				machineSets:            []machinev1beta1.MachineSet{},
+				expectedFailureDomains: []failuredomain.FailureDomain{},
+			}),
+			Entry("with AWS machine sets across three zones", extractFailureDomainsFromMachineSetsTableInput{
+				machineSets: []machinev1beta1.MachineSet{
+					*machineSetBuilder.WithName("worker-us-east-1a").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*machineSetBuilder.WithName("worker-us-east-1b").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+					*machineSetBuilder.WithName("worker-us-east-1c").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+				},
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
+				},
+			}),
+			Entry("with multiple AWS machine sets in the same zone", extractFailureDomainsFromMachineSetsTableInput{
+				machineSets: []machinev1beta1.MachineSet{
+					*machineSetBuilder.WithName("worker-us-east-1a").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*machineSetBuilder.WithName("infra-us-east-1a").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*machineSetBuilder.WithName("worker-us-east-1b").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+				},
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+				},
+			}),
+			Entry("with AWS machine sets out of order, sorts the failure domains", extractFailureDomainsFromMachineSetsTableInput{
+				machineSets: []machinev1beta1.MachineSet{
+					*machineSetBuilder.WithName("worker-us-east-1c").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+					*machineSetBuilder.WithName("worker-us-east-1a").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*machineSetBuilder.WithName("worker-us-east-1b").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+				},
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
+				},
+			}),
+			Entry("with a machine set with an unsupported provider spec", extractFailureDomainsFromMachineSetsTableInput{
+				machineSets: []machinev1beta1.MachineSet{
+					*machineSetBuilder.WithName("worker-azure").WithProviderSpecBuilder(resourcebuilder.AzureProviderSpec()).Build(),
+				},
+				expectedError: fmt.Errorf("error getting failure domain from machine set %s: %w", "worker-azure", fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType)),
+			}),
+		)
+	})
+
+	Context("CountMachinesPerFailureDomain", func() {
+		awsSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		}
+
+		awsMachine := func(name, az string) machinev1beta1.Machine {
+			return *resourcebuilder.Machine().AsMaster().WithName(name).WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone(az)).Build()
+		}
+
+		awsFailureDomainHash := func(az string) string {
+			return failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone(az).WithSubnet(awsSubnet).Build()).Hash()
+		}
+
+		It("should count the machines in each failure domain", func() {
+			machines := []machinev1beta1.Machine{
+				awsMachine("master-0", "us-east-1a"),
+				awsMachine("master-1", "us-east-1b"),
+				awsMachine("master-2", "us-east-1c"),
+				awsMachine("master-3", "us-east-1a"),
+				awsMachine("master-4", "us-east-1b"),
+			}
+
+			counts, err := CountMachinesPerFailureDomain(machines)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(Equal(map[string]int{
+				awsFailureDomainHash("us-east-1a"): 2,
+				awsFailureDomainHash("us-east-1b"): 2,
+				awsFailureDomainHash("us-east-1c"): 1,
+			}))
+		})
+
+		It("should return an empty map when there are no machines", func() {
+			counts, err := CountMachinesPerFailureDomain([]machinev1beta1.Machine{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(BeEmpty())
+		})
+
+		It("should return an error when the machines span multiple platforms", func() {
+			machines := []machinev1beta1.Machine{
+				awsMachine("master-0", "us-east-1a"),
+				*resourcebuilder.Machine().AsMaster().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.GCPProviderSpec()).Build(),
+			}
+
+			_, err := CountMachinesPerFailureDomain(machines)
+			Expect(err).To(MatchError(errMismatchedPlatformTypes))
+			Expect(err).To(MatchError(ContainSubstring("machine master-1 is on platform GCP, expected platform AWS")))
+		})
+	})
+
+	Context("ValidateFailureDomainConsistency", func() {
+		type validateFailureDomainConsistencyTableInput struct {
+			machines         []machinev1beta1.Machine
+			expectedWarnings []string
+		}
+
+		DescribeTable("should warn about machines without a zone", func(in validateFailureDomainConsistencyTableInput) {
+			Expect(ValidateFailureDomainConsistency(in.machines)).To(Equal(in.expectedWarnings))
+		},
+			Entry("when there are no machines", validateFailureDomainConsistencyTableInput{
+				machines:         []machinev1beta1.Machine{},
+				expectedWarnings: []string{},
+			}),
+			Entry("when all machines have a zone", validateFailureDomainConsistencyTableInput{
+				machines: []machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*resourcebuilder.Machine().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+					*resourcebuilder.Machine().WithName("master-2").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+				},
+				expectedWarnings: []string{},
+			}),
+			Entry("when no machines have a zone", validateFailureDomainConsistencyTableInput{
+				machines: []machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("")).Build(),
+					*resourcebuilder.Machine().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("")).Build(),
+				},
+				expectedWarnings: []string{},
+			}),
+			Entry("when one machine is missing a zone", validateFailureDomainConsistencyTableInput{
+				machines: []machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+					*resourcebuilder.Machine().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+					*resourcebuilder.Machine().WithName("master-2").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("")).Build(),
+				},
+				expectedWarnings: []string{
+					"machine master-2 has no zone configured but other control plane machines do",
+				},
+			}),
+		)
+	})
+
+	Context("ValidateFailureDomainCoverage", func() {
+		type validateFailureDomainCoverageTableInput struct {
+			declared                    []failuredomain.FailureDomain
+			machines                    []machinev1beta1.Machine
+			expectedMissingFromMachines []failuredomain.FailureDomain
+			expectedMissingFromDeclared []failuredomain.FailureDomain
+		}
+
+		awsSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		}
+
+		usEast1a := failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(awsSubnet).Build())
+		usEast1b := failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build())
+		usEast1c := failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build())
+		usEast1d := failuredomain.NewAWSFailureDomain(resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1d").WithSubnet(awsSubnet).Build())
+
+		machines := []machinev1beta1.Machine{
+			*resourcebuilder.Machine().WithName("master-0").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build(),
+			*resourcebuilder.Machine().WithName("master-1").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b")).Build(),
+			*resourcebuilder.Machine().WithName("master-2").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+		}
+
+		DescribeTable("should compare the declared failure domains with the machine failure domains", func(in validateFailureDomainCoverageTableInput) {
+			machineFailureDomains, err := ExtractFailureDomainsFromMachines(in.machines)
+			Expect(err).ToNot(HaveOccurred())
+
+			missingFromMachines, missingFromDeclared := ValidateFailureDomainCoverage(in.declared, machineFailureDomains)
+
+			Expect(missingFromMachines).To(ConsistOf(in.expectedMissingFromMachines))
+			Expect(missingFromDeclared).To(ConsistOf(in.expectedMissingFromDeclared))
+		},
+			Entry("when the declared failure domains match the machines", validateFailureDomainCoverageTableInput{
+				declared:                    []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c},
+				machines:                    machines,
+				expectedMissingFromMachines: []failuredomain.FailureDomain{},
+				expectedMissingFromDeclared: []failuredomain.FailureDomain{},
+			}),
+			Entry("when the declared failure domains are reduced", validateFailureDomainCoverageTableInput{
+				declared:                    []failuredomain.FailureDomain{usEast1a, usEast1b},
+				machines:                    machines,
+				expectedMissingFromMachines: []failuredomain.FailureDomain{},
+				expectedMissingFromDeclared: []failuredomain.FailureDomain{usEast1c},
+			}),
+			Entry("when the declared failure domains are increased", validateFailureDomainCoverageTableInput{
+				declared:                    []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1c, usEast1d},
+				machines:                    machines,
+				expectedMissingFromMachines: []failuredomain.FailureDomain{usEast1d},
+				expectedMissingFromDeclared: []failuredomain.FailureDomain{},
+			}),
+			Entry("when the declared failure domains do not match the machines", validateFailureDomainCoverageTableInput{
+				declared:                    []failuredomain.FailureDomain{usEast1a, usEast1b, usEast1d},
+				machines:                    machines,
+				expectedMissingFromMachines: []failuredomain.FailureDomain{usEast1d},
+				expectedMissingFromDeclared: []failuredomain.FailureDomain{usEast1c},
+			}),
+			Entry("when multiple machines share an undeclared failure domain", validateFailureDomainCoverageTableInput{
+				declared: []failuredomain.FailureDomain{usEast1a},
+				machines: append([]machinev1beta1.Machine{
+					*resourcebuilder.Machine().WithName("master-3").WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1c")).Build(),
+				}, machines...),
+				expectedMissingFromMachines: []failuredomain.FailureDomain{},
+				expectedMissingFromDeclared: []failuredomain.FailureDomain{usEast1b, usEast1c},
+			}),
+		)
+	})
+
+	Context("SetInstanceType", func() {
+		type setInstanceTypeTableInput struct {
+			providerConfig       ProviderConfig
+			instanceType         string
+			matchPath            string
+			expectedInstanceType string
+			expectedError        error
+		}
+
+		DescribeTable("should set the instance type in the provider config", func(in setInstanceTypeTableInput) {
+			pc, err := in.providerConfig.SetInstanceType(in.instanceType)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pc).To(HaveField(in.matchPath, Equal(in.expectedInstanceType)))
+		},
+			Entry("with an AWS config", setInstanceTypeTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").Build(),
+					},
+				},
+				instanceType:         "m6i.2xlarge",
+				matchPath:            "AWS().Config().InstanceType",
+				expectedInstanceType: "m6i.2xlarge",
+			}),
+			Entry("with an unsupported platform type", setInstanceTypeTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.BareMetalPlatformType,
+				},
+				instanceType:  "m6i.2xlarge",
+				expectedError: fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.BareMetalPlatformType),
+			}),
+		)
+
+		It("should not modify the original AWS config", func() {
+			pc := &providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").Build(),
+				},
+			}
+
+			_, err := pc.SetInstanceType("m6i.2xlarge")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pc.AWS().Config().InstanceType).To(Equal("m6i.xlarge"))
+		})
+	})
+
+	Context("Merge", func() {
+		type mergeTableInput struct {
+			baseConfig     ProviderConfig
+			overrides      ProviderConfig
+			expectedConfig ProviderConfig
+			expectedError  error
+		}
+
+		awsSubnet := machinev1beta1.AWSResourceReference{
+			ID: stringPtr("subnet-us-east-1b"),
+		}
+
+		DescribeTable("should merge the overrides into the base config", func(in mergeTableInput) {
+			pc, err := in.baseConfig.Merge(in.overrides)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pc).To(Equal(in.expectedConfig))
+		},
+			Entry("with a placement only AWS config over a full base", mergeTableInput{
+				baseConfig: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				overrides: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: machinev1beta1.AWSMachineProviderConfig{
+							Placement: machinev1beta1.Placement{
+								AvailabilityZone: "us-east-1b",
+							},
+						},
+					},
+				},
+				expectedConfig: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").Build(),
+					},
+				},
+			}),
+			Entry("with a placement and subnet AWS config over a full base", mergeTableInput{
+				baseConfig: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				overrides: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: machinev1beta1.AWSMachineProviderConfig{
+							Placement: machinev1beta1.Placement{
+								AvailabilityZone: "us-east-1b",
+							},
+							Subnet: awsSubnet,
+							// Fields other than placement and subnet are not merged.
+							InstanceType: "m6i.2xlarge",
+						},
+					},
+				},
+				expectedConfig: providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").WithSubnet(awsSubnet).Build(),
+					},
+				},
+			}),
+			Entry("with a zone only GCP config over a full base", mergeTableInput{
+				baseConfig: providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().WithZone("us-central1-a").Build(),
+					},
+				},
+				overrides: providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: machinev1beta1.GCPMachineProviderSpec{
+							Zone: "us-central1-b",
+						},
+					},
+				},
+				expectedConfig: providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().WithZone("us-central1-b").Build(),
+					},
+				},
+			}),
+			Entry("with mismatched platform types", mergeTableInput{
+				baseConfig: providerConfig{
+					platformType: configv1.AWSPlatformType,
+				},
+				overrides: providerConfig{
+					platformType: configv1.GCPPlatformType,
+				},
+				expectedError: errMismatchedPlatformTypes,
+			}),
+			Entry("with an unsupported platform type", mergeTableInput{
+				baseConfig: providerConfig{
+					platformType: configv1.BareMetalPlatformType,
+				},
+				overrides: providerConfig{
+					platformType: configv1.BareMetalPlatformType,
+				},
+				expectedError: fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.BareMetalPlatformType),
+			}),
+		)
+	})
+
+	Context("ExtractFailureDomain", func() {
+		type extractFailureDomainTableInput struct {
+			providerConfig        ProviderConfig
+			expectedFailureDomain failuredomain.FailureDomain
+		}
+		filterSubnet := machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{{
+				Name:   "tag:Name",
+				Values: []string{"aws-subnet-12345678"},
+			}},
+		}
+
+		DescribeTable("should correctly extract the failure domain", func(in extractFailureDomainTableInput) {
+			fd := in.providerConfig.ExtractFailureDomain()
+
+			Expect(fd).To(Equal(in.expectedFailureDomain))
+		},
+			Entry("with an AWS us-east-1a failure domain", extractFailureDomainTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(convertAWSResourceReferenceV1ToV1Beta1(&filterSubnet)).Build(),
+					},
+				},
+				expectedFailureDomain: failuredomain.NewAWSFailureDomain(
+					resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet).Build(),
+				),
+			}),
+			Entry("with an AWS us-east-1b failure domain", extractFailureDomainTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").WithSubnet(convertAWSResourceReferenceV1ToV1Beta1(&filterSubnet)).Build(),
+					},
+				},
+				expectedFailureDomain: failuredomain.NewAWSFailureDomain(
+					resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet).Build(),
+				),
+			}),
+		)
+	})
+
+	Context("Equal", func() {
+		type equalTableInput struct {
+			basePC        ProviderConfig
+			comparePC     ProviderConfig
+			expectedEqual bool
+			expectedError error
+		}
+
+		DescribeTable("should compare provider configs", func(in equalTableInput) {
+			equal, err := in.basePC.Equal(in.comparePC)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(equal).To(Equal(in.expectedEqual), "Equality of provider configs was not as expected")
+		},
+			Entry("with different platform types", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+				},
+				expectedEqual: false,
+				expectedError: errMismatchedPlatformTypes,
+			}),
+			Entry("with matching AWS configs", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				expectedEqual: true,
+			}),
+			Entry("with mis-matched AWS configs", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").Build(),
+					},
+				},
+				expectedEqual: false,
+			}),
+			Entry("with a subnet derived from a v1 failure domain and an equivalent v1beta1 subnet", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+					}.InjectFailureDomain(machinev1.AWSFailureDomain{
+						Placement: machinev1.AWSFailureDomainPlacement{
+							AvailabilityZone: "us-east-1a",
+						},
+						Subnet: &machinev1.AWSResourceReference{
+							Type: machinev1.AWSFiltersReferenceType,
+							Filters: &[]machinev1.AWSResourceFilter{{
+								Name: "tag:Name",
+							}},
+						},
+					}),
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{
+							Filters: []machinev1beta1.Filter{{
+								Name:   "tag:Name",
+								Values: []string{},
+							}},
+						}).Build(),
+					},
+				},
+				expectedEqual: true,
+			}),
+			Entry("with an empty filter subnet derived from a v1 failure domain and an unset v1beta1 subnet", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+					}.InjectFailureDomain(machinev1.AWSFailureDomain{
+						Placement: machinev1.AWSFailureDomainPlacement{
+							AvailabilityZone: "us-east-1a",
+						},
+						Subnet: &machinev1.AWSResourceReference{
+							Type:    machinev1.AWSFiltersReferenceType,
+							Filters: &[]machinev1.AWSResourceFilter{},
+						},
+					}),
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{}).Build(),
+					},
+				},
+				expectedEqual: true,
+			}),
+			Entry("with subnets with different filter values", equalTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(machinev1beta1.AWSResourceReference{
+							Filters: []machinev1beta1.Filter{{Name: "tag:Name", Values: []string{"aws-subnet-a"}}},
+						}).Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithSubnet(machinev1beta1.AWSResourceReference{
+							Filters: []machinev1beta1.Filter{{Name: "tag:Name", Values: []string{"aws-subnet-b"}}},
+						}).Build(),
+					},
+				},
+				expectedEqual: false,
+			}),
+		)
+	})
+
+	Context("RawExtensionsEqual", func() {
+		type rawExtensionsEqualTableInput struct {
+			a             resourcebuilder.RawExtensionBuilder
+			b             resourcebuilder.RawExtensionBuilder
+			platform      configv1.PlatformType
+			expectedEqual bool
+			expectedError error
+		}
+
+		DescribeTable("should compare raw extensions", func(in rawExtensionsEqualTableInput) {
+			equal, err := RawExtensionsEqual(*in.a.BuildRawExtension(), *in.b.BuildRawExtension(), in.platform)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(equal).To(Equal(in.expectedEqual), "Equality of raw extensions was not as expected")
+		},
+			Entry("with matching AWS raw extensions", rawExtensionsEqualTableInput{
+				a:             resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a"),
+				b:             resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a"),
+				platform:      configv1.AWSPlatformType,
+				expectedEqual: true,
+			}),
+			Entry("with mis-matched AWS raw extensions", rawExtensionsEqualTableInput{
+				a:             resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a"),
+				b:             resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b"),
+				platform:      configv1.AWSPlatformType,
+				expectedEqual: false,
+			}),
+			Entry("with an unsupported platform type", rawExtensionsEqualTableInput{
+				a:             resourcebuilder.AWSProviderSpec(),
+				b:             resourcebuilder.AWSProviderSpec(),
+				platform:      configv1.AzurePlatformType,
+				expectedEqual: false,
+				expectedError: fmt.Errorf("could not decode first provider config: %w", fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType)),
+			}),
+		)
+	})
+
+	Context("EqualIgnoringFields", func() {
+		type equalIgnoringFieldsTableInput struct {
+			baseProviderSpec    resourcebuilder.RawExtensionBuilder
+			compareProviderSpec resourcebuilder.RawExtensionBuilder
+			ignoredFields       []string
+			expectedEqual       bool
+			expectedError       error
+		}
+
+		DescribeTable("should compare provider configs ignoring fields", func(in equalIgnoringFieldsTableInput) {
+			basePC, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: in.baseProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			comparePC, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: in.compareProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			equal, err := basePC.EqualIgnoringFields(comparePC, in.ignoredFields...)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(equal).To(Equal(in.expectedEqual), "Equality of provider configs was not as expected")
+		},
+			Entry("with AWS configs differing in user data secret and no ignored fields", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithUserDataSecret("aws-user-data-different"),
+				expectedEqual:       false,
+			}),
+			Entry("with AWS configs differing in user data secret and the user data secret ignored", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithUserDataSecret("aws-user-data-different"),
+				ignoredFields:       []string{"userDataSecret"},
+				expectedEqual:       true,
+			}),
+			Entry("with AWS configs differing in user data secret and a nested ignored field", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithUserDataSecret("aws-user-data-different"),
+				ignoredFields:       []string{"userDataSecret.name"},
+				expectedEqual:       true,
+			}),
+			Entry("with AWS configs differing in user data secret and instance type and the user data secret ignored", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithUserDataSecret("aws-user-data-different").WithInstanceType("m6i.2xlarge"),
+				ignoredFields:       []string{"userDataSecret"},
+				expectedEqual:       false,
+			}),
+			Entry("with an ignored field that does not exist", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec(),
+				ignoredFields:       []string{"doesNotExist", "instanceType.doesNotExist"},
+				expectedEqual:       true,
+			}),
+			Entry("with different platform types", equalIgnoringFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.GCPProviderSpec(),
+				expectedEqual:       false,
+				expectedError:       errMismatchedPlatformTypes,
+			}),
+		)
+	})
+
+	Context("DiffFields", func() {
+		type diffFieldsTableInput struct {
+			baseProviderSpec    resourcebuilder.RawExtensionBuilder
+			compareProviderSpec resourcebuilder.RawExtensionBuilder
+			expectedFields      []string
+			expectedError       error
+		}
+
+		DescribeTable("should return the fields that differ between provider configs", func(in diffFieldsTableInput) {
+			basePC, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: in.baseProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			comparePC, err := NewProviderConfigFromMachineSpec(machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{Value: in.compareProviderSpec.BuildRawExtension()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			fields, err := basePC.DiffFields(comparePC)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(fields).To(Equal(in.expectedFields))
+		},
+			Entry("with identical AWS configs", diffFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec(),
+				expectedFields:      []string{},
+			}),
+			Entry("with AWS configs differing in availability zone and instance type", diffFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a"),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").WithInstanceType("m6i.2xlarge"),
+				expectedFields:      []string{"InstanceType", "Placement.AvailabilityZone"},
+			}),
+			Entry("with AWS configs differing in user data secret", diffFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithUserDataSecret("aws-user-data-different"),
+				expectedFields:      []string{"UserDataSecret.Name"},
+			}),
+			Entry("with AWS configs differing by one tag value", diffFieldsTableInput{
+				baseProviderSpec: resourcebuilder.AWSProviderSpec().WithTags([]machinev1beta1.TagSpecification{
+					{Name: "owner", Value: "team-a"},
+					{Name: "environment", Value: "production"},
+				}),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithTags([]machinev1beta1.TagSpecification{
+					{Name: "environment", Value: "production"},
+					{Name: "owner", Value: "team-b"},
+				}),
+				expectedFields: []string{"Tags[owner]"},
+			}),
+			Entry("with AWS configs where a tag was added and another removed", diffFieldsTableInput{
+				baseProviderSpec: resourcebuilder.AWSProviderSpec().WithTags([]machinev1beta1.TagSpecification{
+					{Name: "owner", Value: "team-a"},
+					{Name: "cost-center", Value: "1234"},
+				}),
+				compareProviderSpec: resourcebuilder.AWSProviderSpec().WithTags([]machinev1beta1.TagSpecification{
+					{Name: "owner", Value: "team-a"},
+					{Name: "environment", Value: "production"},
+				}),
+				expectedFields: []string{"Tags[cost-center]", "Tags[environment]"},
+			}),
+			Entry("with GCP configs differing in zone", diffFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.GCPProviderSpec().WithZone("us-central1-a"),
+				compareProviderSpec: resourcebuilder.GCPProviderSpec().WithZone("us-central1-b"),
+				expectedFields:      []string{"Zone"},
+			}),
+			Entry("with different platform types", diffFieldsTableInput{
+				baseProviderSpec:    resourcebuilder.AWSProviderSpec(),
+				compareProviderSpec: resourcebuilder.GCPProviderSpec(),
+				expectedError:       errMismatchedPlatformTypes,
+			}),
+		)
+	})
+
+	Context("Validate", func() {
+		type validateTableInput struct {
+			providerConfig ProviderConfig
+			expectedErrors field.ErrorList
+		}
+
+		DescribeTable("should validate the provider config", func(in validateTableInput) {
+			Expect(in.providerConfig.Validate()).To(ConsistOf(in.expectedErrors))
+		},
+			Entry("with a valid AWS config", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{},
+			}),
+			Entry("with an AWS config with an empty instance type", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithInstanceType("").Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Required(field.NewPath("instanceType"), "an instance type must be specified"),
+				},
+			}),
+			Entry("with an AWS config with an empty AMI", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithAMI(machinev1beta1.AWSResourceReference{}).Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Required(field.NewPath("ami"), "a boot image must be specified"),
+				},
+			}),
+			Entry("with an AWS config with a root volume below the minimum size", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithRootVolumeSize(4).Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Invalid(field.NewPath("blockDevices").Index(0).Child("ebs", "volumeSize"), int64(4), "root volume size 4 is below the minimum of 8"),
+				},
+			}),
+			Entry("with an AWS config with a root volume of the minimum size", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithRootVolumeSize(8).Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{},
+			}),
+			Entry("with a GCP config with a boot disk below the minimum size", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: func() machinev1beta1.GCPMachineProviderSpec {
+							spec := *resourcebuilder.GCPProviderSpec().Build()
+							spec.Disks[0].SizeGB = 10
+
+							return spec
+						}(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Invalid(field.NewPath("disks").Index(0).Child("sizeGb"), int64(10), "root volume size 10 is below the minimum of 16"),
+				},
+			}),
+			Entry("with an AWS config with an empty instance type and AMI", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithInstanceType("").WithAMI(machinev1beta1.AWSResourceReference{}).Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Required(field.NewPath("instanceType"), "an instance type must be specified"),
+					field.Required(field.NewPath("ami"), "a boot image must be specified"),
+				},
+			}),
+			Entry("with an AWS config with an empty user data secret name", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *resourcebuilder.AWSProviderSpec().WithUserDataSecret("").Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Required(field.NewPath("userDataSecret"), "a user data secret must be specified"),
+				},
+			}),
+			Entry("with an AWS config without a credentials secret, as it is defaulted", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: func() machinev1beta1.AWSMachineProviderConfig {
+							spec := *resourcebuilder.AWSProviderSpec().Build()
+							spec.CredentialsSecret = nil
+
+							return spec
+						}(),
+					},
+				},
+				expectedErrors: field.ErrorList{},
+			}),
+			Entry("with a GCP config without secrets", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: func() machinev1beta1.GCPMachineProviderSpec {
+							spec := *resourcebuilder.GCPProviderSpec().Build()
+							spec.UserDataSecret = nil
+							spec.CredentialsSecret = nil
+
+							return spec
+						}(),
+					},
+				},
+				expectedErrors: field.ErrorList{
+					field.Required(field.NewPath("userDataSecret"), "a user data secret must be specified"),
+				},
+			}),
+			Entry("with a valid GCP config", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *resourcebuilder.GCPProviderSpec().Build(),
+					},
+				},
+				expectedErrors: field.ErrorList{},
+			}),
+			Entry("with an unsupported platform type", validateTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.BareMetalPlatformType,
+				},
+				expectedErrors: field.ErrorList{},
+			}),
+		)
+	})
+
+	Context("RawConfig", func() {
+		type rawConfigTableInput struct {
+			providerConfig ProviderConfig
 			expectedError  error
 			expectedOut    []byte
 		}
@@ -369,6 +2122,128 @@ var _ = Describe("Provider Config", func() {
 				expectedOut: resourcebuilder.AWSProviderSpec().BuildRawExtension().Raw,
 			}),
 		)
+
+		It("should marshal an indented config with the same content", func() {
+			pc := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+				},
+			}
+
+			out, err := pc.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			indentedOut, err := pc.RawConfigIndent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(indentedOut).To(ContainSubstring("\n  \"ami\": {"))
+
+			compact := machinev1beta1.AWSMachineProviderConfig{}
+			Expect(json.Unmarshal(out, &compact)).To(Succeed())
+
+			indented := machinev1beta1.AWSMachineProviderConfig{}
+			Expect(json.Unmarshal(indentedOut, &indented)).To(Succeed())
+
+			Expect(indented).To(Equal(compact))
+		})
+
+		It("should return an error when indenting an unsupported platform type", func() {
+			_, err := providerConfig{platformType: configv1.BareMetalPlatformType}.RawConfigIndent()
+			Expect(err).To(MatchError(errUnsupportedPlatformType))
+		})
+
+		It("should marshal AWS tags in a stable order", func() {
+			tags := []machinev1beta1.TagSpecification{
+				{Name: "kubernetes.io/cluster/cpms-cluster-test-id", Value: "owned"},
+				{Name: "environment", Value: "test"},
+				{Name: "team", Value: "control-plane"},
+			}
+
+			reorderedTags := []machinev1beta1.TagSpecification{tags[2], tags[0], tags[1]}
+
+			baseConfig := *resourcebuilder.AWSProviderSpec().WithTags(tags).Build()
+			reorderedConfig := *resourcebuilder.AWSProviderSpec().WithTags(reorderedTags).Build()
+
+			baseOut, err := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws:          AWSProviderConfig{providerConfig: baseConfig},
+			}.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			reorderedOut, err := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws:          AWSProviderConfig{providerConfig: reorderedConfig},
+			}.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reorderedOut).To(Equal(baseOut))
+
+			By("Not modifying the order of the stored tags")
+			Expect(reorderedConfig.Tags).To(Equal(reorderedTags))
+		})
+
+		It("should preserve all AWS tags when round tripping the config", func() {
+			tags := []machinev1beta1.TagSpecification{
+				{Name: "kubernetes.io/cluster/cpms-cluster-test-id", Value: "owned"},
+				{Name: "environment", Value: "test"},
+				{Name: "team", Value: "control-plane"},
+			}
+
+			pc := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws:          AWSProviderConfig{providerConfig: *resourcebuilder.AWSProviderSpec().WithTags(tags).Build()},
+			}
+
+			out, err := pc.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			roundTripped, err := newAWSProviderConfig(&runtime.RawExtension{Raw: out})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(roundTripped.AWS().Config().Tags).To(ConsistOf(tags))
+		})
+	})
+
+	Context("JSON", func() {
+		It("should round trip an AWS config", func() {
+			pc := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1b").Build(),
+				},
+			}
+
+			out, err := json.Marshal(pc)
+			Expect(err).ToNot(HaveOccurred())
+
+			roundTripped := providerConfig{}
+			Expect(json.Unmarshal(out, &roundTripped)).To(Succeed())
+
+			Expect(roundTripped.Type()).To(Equal(configv1.AWSPlatformType))
+			Expect(roundTripped.AWS().Config()).To(Equal(pc.AWS().Config()))
+		})
+
+		It("should include the platform type and config", func() {
+			pc := providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *resourcebuilder.AWSProviderSpec().Build(),
+				},
+			}
+
+			out, err := json.Marshal(pc)
+			Expect(err).ToNot(HaveOccurred())
+
+			expected := fmt.Sprintf(`{"platformType":"AWS","config":%s}`, resourcebuilder.AWSProviderSpec().BuildRawExtension().Raw)
+			Expect(out).To(MatchJSON(expected))
+		})
+
+		It("should return an error when unmarshalling an unsupported platform type", func() {
+			pc := providerConfig{}
+			err := json.Unmarshal([]byte(`{"platformType":"Azure","config":{}}`), &pc)
+
+			Expect(err).To(MatchError(fmt.Errorf("%w: %s", errUnsupportedPlatformType, configv1.AzurePlatformType)))
+		})
 	})
 
 	Context("ConvertAWSResourceReference", func() {
@@ -419,7 +2294,7 @@ var _ = Describe("Provider Config", func() {
 		}
 
 		DescribeTable("converts correctly to V1", func(in convertAWSResourceReferenceInput) {
-			Expect(in.awsResourceV1).To(Equal(convertAWSResourceReferenceV1Beta1ToV1(in.awsResourceBeta1)))
+			Expect(in.awsResourceV1).To(Equal(failuredomain.ConvertAWSResourceReferenceV1Beta1ToV1(in.awsResourceBeta1)))
 		},
 			Entry("with ID", idInput),
 			Entry("with ARN", arnInput),
@@ -437,7 +2312,7 @@ var _ = Describe("Provider Config", func() {
 		)
 
 		DescribeTable("is the same after back and forth conversion - V1", func(in convertAWSResourceReferenceInput) {
-			converted := convertAWSResourceReferenceV1Beta1ToV1(convertAWSResourceReferenceV1ToV1Beta1(in.awsResourceV1))
+			converted := failuredomain.ConvertAWSResourceReferenceV1Beta1ToV1(convertAWSResourceReferenceV1ToV1Beta1(in.awsResourceV1))
 			Expect(in.awsResourceV1).To(Equal(converted))
 		},
 			Entry("with ID", idInput),
@@ -447,7 +2322,7 @@ var _ = Describe("Provider Config", func() {
 		)
 
 		DescribeTable("is the same after back and forth conversion - Beta1", func(in convertAWSResourceReferenceInput) {
-			converted := convertAWSResourceReferenceV1ToV1Beta1(convertAWSResourceReferenceV1Beta1ToV1(in.awsResourceBeta1))
+			converted := convertAWSResourceReferenceV1ToV1Beta1(failuredomain.ConvertAWSResourceReferenceV1Beta1ToV1(in.awsResourceBeta1))
 			Expect(in.awsResourceBeta1).To(Equal(converted))
 		},
 			Entry("with ID", idInput),
@@ -87,6 +87,8 @@ type AWSFailureDomainBuilder struct {
 }
 
 // Build builds an AWS failuredomain from the configuration.
+// The availability zone is not defaulted, so a failure domain built with only
+// a subnet is a subnet-only failure domain.
 func (a AWSFailureDomainBuilder) Build() machinev1.AWSFailureDomain {
 	return machinev1.AWSFailureDomain{
 		Placement: machinev1.AWSFailureDomainPlacement{
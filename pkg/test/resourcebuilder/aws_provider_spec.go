@@ -28,8 +28,13 @@ import (
 // AWSProviderSpec creates a new AWS machine config builder.
 func AWSProviderSpec() AWSProviderSpecBuilder {
 	return AWSProviderSpecBuilder{
+		ami: machinev1beta1.AWSResourceReference{
+			ID: stringPtr("aws-ami-12345678"),
+		},
 		availabilityZone: "us-east-1a",
 		instanceType:     "m6i.xlarge",
+		region:           "us-east-1",
+		rootVolumeSize:   120,
 		securityGroups: []machinev1beta1.AWSResourceReference{
 			{
 				Filters: []machinev1beta1.Filter{
@@ -52,15 +57,21 @@ func AWSProviderSpec() AWSProviderSpecBuilder {
 				},
 			},
 		},
+		userDataSecret: "aws-user-data-12345678",
 	}
 }
 
 // AWSProviderSpecBuilder is used to build out a AWS machine config object.
 type AWSProviderSpecBuilder struct {
+	ami              machinev1beta1.AWSResourceReference
 	availabilityZone string
 	instanceType     string
+	region           string
+	rootVolumeSize   int64
 	securityGroups   []machinev1beta1.AWSResourceReference
 	subnet           machinev1beta1.AWSResourceReference
+	tags             []machinev1beta1.TagSpecification
+	userDataSecret   string
 }
 
 // Build builds a new AWS machine config based on the configuration provided.
@@ -70,14 +81,12 @@ func (m AWSProviderSpecBuilder) Build() *machinev1beta1.AWSMachineProviderConfig
 			APIVersion: "awsproviderconfig.openshift.io/v1beta1",
 			Kind:       "AWSMachineProviderConfig",
 		},
-		AMI: machinev1beta1.AWSResourceReference{
-			ID: stringPtr("aws-ami-12345678"),
-		},
+		AMI: m.ami,
 		BlockDevices: []machinev1beta1.BlockDeviceMappingSpec{
 			{
 				EBS: &machinev1beta1.EBSBlockDeviceSpec{
 					Encrypted:  boolPtr(true),
-					VolumeSize: int64Ptr(120),
+					VolumeSize: int64Ptr(m.rootVolumeSize),
 					VolumeType: stringPtr("gp3"),
 				},
 			},
@@ -100,13 +109,14 @@ func (m AWSProviderSpecBuilder) Build() *machinev1beta1.AWSMachineProviderConfig
 			},
 		},
 		Placement: machinev1beta1.Placement{
-			Region:           "us-east-1",
+			Region:           m.region,
 			AvailabilityZone: m.availabilityZone,
 		},
 		SecurityGroups: m.securityGroups,
 		Subnet:         m.subnet,
+		Tags:           m.tags,
 		UserDataSecret: &corev1.LocalObjectReference{
-			Name: "aws-user-data-12345678",
+			Name: m.userDataSecret,
 		},
 	}
 }
@@ -126,6 +136,12 @@ func (m AWSProviderSpecBuilder) BuildRawExtension() *runtime.RawExtension {
 	}
 }
 
+// WithAMI sets the AMI for the AWS machine config builder.
+func (m AWSProviderSpecBuilder) WithAMI(ami machinev1beta1.AWSResourceReference) AWSProviderSpecBuilder {
+	m.ami = ami
+	return m
+}
+
 // WithAvailabilityZone sets the availabilityZone for the AWS machine config builder.
 func (m AWSProviderSpecBuilder) WithAvailabilityZone(az string) AWSProviderSpecBuilder {
 	m.availabilityZone = az
@@ -138,6 +154,18 @@ func (m AWSProviderSpecBuilder) WithInstanceType(instanceType string) AWSProvide
 	return m
 }
 
+// WithRegion sets the region for the AWS machine config builder.
+func (m AWSProviderSpecBuilder) WithRegion(region string) AWSProviderSpecBuilder {
+	m.region = region
+	return m
+}
+
+// WithRootVolumeSize sets the size, in GiB, of the root volume for the AWS machine config builder.
+func (m AWSProviderSpecBuilder) WithRootVolumeSize(size int64) AWSProviderSpecBuilder {
+	m.rootVolumeSize = size
+	return m
+}
+
 // WithSecurityGroups sets the securityGroups for the AWS machine config builder.
 func (m AWSProviderSpecBuilder) WithSecurityGroups(sgs []machinev1beta1.AWSResourceReference) AWSProviderSpecBuilder {
 	m.securityGroups = sgs
@@ -149,3 +177,15 @@ func (m AWSProviderSpecBuilder) WithSubnet(subnet machinev1beta1.AWSResourceRefe
 	m.subnet = subnet
 	return m
 }
+
+// WithTags sets the tags for the AWS machine config builder.
+func (m AWSProviderSpecBuilder) WithTags(tags []machinev1beta1.TagSpecification) AWSProviderSpecBuilder {
+	m.tags = tags
+	return m
+}
+
+// WithUserDataSecret sets the user data secret name for the AWS machine config builder.
+func (m AWSProviderSpecBuilder) WithUserDataSecret(name string) AWSProviderSpecBuilder {
+	m.userDataSecret = name
+	return m
+}
@@ -20,6 +20,42 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// conditionAvailable is the Available ControlPlaneMachineSet condition type.
+	conditionAvailable = "Available"
+
+	// conditionDegraded is the Degraded ControlPlaneMachineSet condition type.
+	conditionDegraded = "Degraded"
+
+	// conditionProgressing is the Progressing ControlPlaneMachineSet condition type.
+	conditionProgressing = "Progressing"
+)
+
+// Condition creates a new status condition with the given type, status, reason and message.
+func Condition(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return StatusCondition().
+		WithType(conditionType).
+		WithStatus(conditionStatus).
+		WithReason(reason).
+		WithMessage(message).
+		Build()
+}
+
+// AvailableCondition creates a new Available status condition.
+func AvailableCondition(conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return Condition(conditionAvailable, conditionStatus, reason, message)
+}
+
+// DegradedCondition creates a new Degraded status condition.
+func DegradedCondition(conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return Condition(conditionDegraded, conditionStatus, reason, message)
+}
+
+// ProgressingCondition creates a new Progressing status condition.
+func ProgressingCondition(conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return Condition(conditionProgressing, conditionStatus, reason, message)
+}
+
 // StatusCondition creates a new status condition builder.
 func StatusCondition() StatusConditionBuilder {
 	return StatusConditionBuilder{}
@@ -20,6 +20,7 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -52,6 +53,7 @@ type ControlPlaneMachineSetBuilder struct {
 	machineTemplateBuilder ControlPlaneMachineSetTemplateBuilder
 	name                   string
 	namespace              string
+	ownerReferences        []metav1.OwnerReference
 	replicas               int32
 	selector               metav1.LabelSelector
 	strategyType           machinev1.ControlPlaneMachineSetStrategyType
@@ -62,9 +64,10 @@ type ControlPlaneMachineSetBuilder struct {
 func (m ControlPlaneMachineSetBuilder) Build() *machinev1.ControlPlaneMachineSet {
 	cpms := &machinev1.ControlPlaneMachineSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:       m.name,
-			Namespace:  m.namespace,
-			Generation: m.generation,
+			Name:            m.name,
+			Namespace:       m.namespace,
+			Generation:      m.generation,
+			OwnerReferences: m.ownerReferences,
 		},
 		Spec: machinev1.ControlPlaneMachineSetSpec{
 			Replicas: int32Ptr(m.replicas),
@@ -103,6 +106,19 @@ func (m ControlPlaneMachineSetBuilder) WithNamespace(namespace string) ControlPl
 	return m
 }
 
+// WithOwnerReferences sets the owner references for the controlplanemachineset builder.
+func (m ControlPlaneMachineSetBuilder) WithOwnerReferences(ownerReferences []metav1.OwnerReference) ControlPlaneMachineSetBuilder {
+	m.ownerReferences = ownerReferences
+	return m
+}
+
+// WithControllerOwner sets a controller owner reference to the owner for the controlplanemachineset builder.
+// This replaces any owner references previously set on the controlplanemachineset builder.
+func (m ControlPlaneMachineSetBuilder) WithControllerOwner(owner client.Object) ControlPlaneMachineSetBuilder {
+	m.ownerReferences = []metav1.OwnerReference{controllerOwnerReference(owner)}
+	return m
+}
+
 // WithGeneration sets the generation for the controlerplanemachineset builder.
 func (m ControlPlaneMachineSetBuilder) WithGeneration(generation int64) ControlPlaneMachineSetBuilder {
 	m.generation = generation
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcebuilder
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+)
+
+// FailureDomains creates a new failure domains builder for the given platform.
+// This allows table driven tests to be parametrised by platform type.
+// Platforms without a failure domains builder build failure domains with only the platform set.
+func FailureDomains(platform configv1.PlatformType) OpenShiftMachineV1Beta1FailureDomainsBuilder {
+	switch platform {
+	case configv1.AWSPlatformType:
+		return AWSFailureDomains()
+	case configv1.AzurePlatformType:
+		return AzureFailureDomains()
+	case configv1.GCPPlatformType:
+		return GCPFailureDomains()
+	case configv1.OpenStackPlatformType:
+		return OpenStackFailureDomains()
+	default:
+		return platformFailureDomainsBuilder{platform: platform}
+	}
+}
+
+// platformFailureDomainsBuilder is used to build failure domains for platforms
+// that do not have a specific failure domains builder.
+type platformFailureDomainsBuilder struct {
+	platform configv1.PlatformType
+}
+
+// BuildFailureDomains builds a failuredomains from the configuration.
+func (p platformFailureDomainsBuilder) BuildFailureDomains() machinev1.FailureDomains {
+	return machinev1.FailureDomains{
+		Platform: p.platform,
+	}
+}
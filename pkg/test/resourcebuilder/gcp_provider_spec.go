@@ -28,13 +28,20 @@ import (
 // GCPProviderSpec creates a new GCP machine config builder.
 func GCPProviderSpec() GCPProviderSpecBuilder {
 	return GCPProviderSpecBuilder{
-		zone: "us-central1-a",
+		networkInterfaces: []*machinev1beta1.GCPNetworkInterface{{
+			Network:    "gcp-network-12345678",
+			Subnetwork: "gcp-subnetwork-12345678",
+		}},
+		region: "us-central1",
+		zone:   "us-central1-a",
 	}
 }
 
 // GCPProviderSpecBuilder is used to build a GCP machine config object.
 type GCPProviderSpecBuilder struct {
-	zone string
+	networkInterfaces []*machinev1beta1.GCPNetworkInterface
+	region            string
+	zone              string
 }
 
 // Build builds a new GCP machine config based on the configuration provided.
@@ -52,17 +59,14 @@ func (m GCPProviderSpecBuilder) Build() *machinev1beta1.GCPMachineProviderSpec {
 			"gcp-target-pool-12345678",
 		},
 		DeletionProtection: false,
-		NetworkInterfaces: []*machinev1beta1.GCPNetworkInterface{{
-			Network:    "gcp-network-12345678",
-			Subnetwork: "gcp-subnetwork-12345678",
-		}},
+		NetworkInterfaces:  m.networkInterfaces,
 		CredentialsSecret: &corev1.LocalObjectReference{
 			Name: "gcp-cloud-credentials",
 		},
 		Zone:         m.zone,
 		CanIPForward: false,
 		ProjectID:    "openshift-cpms-unit-tests",
-		Region:       "us-central1",
+		Region:       m.region,
 		Disks: []*machinev1beta1.GCPDisk{
 			{
 				AutoDelete: true,
@@ -101,6 +105,18 @@ func (m GCPProviderSpecBuilder) BuildRawExtension() *runtime.RawExtension {
 	}
 }
 
+// WithNetworkInterfaces sets the network interfaces for the GCP machine config builder.
+func (m GCPProviderSpecBuilder) WithNetworkInterfaces(networkInterfaces []*machinev1beta1.GCPNetworkInterface) GCPProviderSpecBuilder {
+	m.networkInterfaces = networkInterfaces
+	return m
+}
+
+// WithRegion sets the region for the GCP machine config builder.
+func (m GCPProviderSpecBuilder) WithRegion(region string) GCPProviderSpecBuilder {
+	m.region = region
+	return m
+}
+
 // WithZone sets the zone for the GCP machine config builder.
 func (m GCPProviderSpecBuilder) WithZone(zone string) GCPProviderSpecBuilder {
 	m.zone = zone
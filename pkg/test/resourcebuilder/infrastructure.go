@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcebuilder
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// infrastructureName is the name of the cluster wide Infrastructure singleton.
+	infrastructureName = "cluster"
+)
+
+// Infrastructure creates a new infrastructure builder.
+func Infrastructure() InfrastructureBuilder {
+	return InfrastructureBuilder{
+		name:               infrastructureName,
+		infrastructureName: "cpms-cluster-test-id",
+	}
+}
+
+// InfrastructureBuilder is used to build out an infrastructure object.
+type InfrastructureBuilder struct {
+	name               string
+	infrastructureName string
+	platformType       configv1.PlatformType
+}
+
+// Build builds a new infrastructure based on the configuration provided.
+func (i InfrastructureBuilder) Build() *configv1.Infrastructure {
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: i.name,
+		},
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: i.infrastructureName,
+		},
+	}
+
+	if i.platformType != "" {
+		infra.Status.PlatformStatus = &configv1.PlatformStatus{
+			Type: i.platformType,
+		}
+	}
+
+	return infra
+}
+
+// WithName sets the name for the infrastructure builder.
+func (i InfrastructureBuilder) WithName(name string) InfrastructureBuilder {
+	i.name = name
+	return i
+}
+
+// WithInfrastructureName sets the infrastructure name status field for the infrastructure builder.
+func (i InfrastructureBuilder) WithInfrastructureName(infrastructureName string) InfrastructureBuilder {
+	i.infrastructureName = infrastructureName
+	return i
+}
+
+// WithPlatformType sets the platform status type for the infrastructure builder.
+func (i InfrastructureBuilder) WithPlatformType(platformType configv1.PlatformType) InfrastructureBuilder {
+	i.platformType = platformType
+	return i
+}
@@ -20,6 +20,7 @@ import (
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -32,16 +33,40 @@ func Machine() MachineBuilder {
 	return MachineBuilder{}
 }
 
+// ControlPlaneMachines builds count control plane machines from the machine builder provided.
+// Each machine is assigned a provider spec from the provider spec builders based on its index,
+// wrapping around when there are fewer provider spec builders than machines.
+// This allows tests to spread control plane machines across failure domains by providing
+// one provider spec builder per failure domain.
+func ControlPlaneMachines(count int, machineBuilder MachineBuilder, providerSpecBuilders ...RawExtensionBuilder) []*machinev1beta1.Machine {
+	machines := []*machinev1beta1.Machine{}
+
+	for i := 0; i < count; i++ {
+		builder := machineBuilder.AsMaster()
+
+		if len(providerSpecBuilders) > 0 {
+			builder = builder.WithProviderSpecBuilder(providerSpecBuilders[i%len(providerSpecBuilders)])
+		}
+
+		machines = append(machines, builder.Build())
+	}
+
+	return machines
+}
+
 // MachineBuilder is used to build out a machine object.
 type MachineBuilder struct {
 	generateName        string
 	name                string
 	namespace           string
 	labels              map[string]string
+	ownerReferences     []metav1.OwnerReference
+	providerID          *string
 	providerSpecBuilder RawExtensionBuilder
 
 	// status fields
 	errorMessage *string
+	errorReason  *machinev1beta1.MachineStatusError
 	nodeRef      *corev1.ObjectReference
 	phase        *string
 }
@@ -50,13 +75,18 @@ type MachineBuilder struct {
 func (m MachineBuilder) Build() *machinev1beta1.Machine {
 	machine := &machinev1beta1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: m.generateName,
-			Name:         m.name,
-			Namespace:    m.namespace,
-			Labels:       m.labels,
+			GenerateName:    m.generateName,
+			Name:            m.name,
+			Namespace:       m.namespace,
+			Labels:          m.labels,
+			OwnerReferences: m.ownerReferences,
+		},
+		Spec: machinev1beta1.MachineSpec{
+			ProviderID: m.providerID,
 		},
 		Status: machinev1beta1.MachineStatus{
 			ErrorMessage: m.errorMessage,
+			ErrorReason:  m.errorReason,
 			Phase:        m.phase,
 			NodeRef:      m.nodeRef,
 		},
@@ -118,6 +148,25 @@ func (m MachineBuilder) WithNamespace(namespace string) MachineBuilder {
 	return m
 }
 
+// WithOwnerReferences sets the owner references for the machine builder.
+func (m MachineBuilder) WithOwnerReferences(ownerReferences []metav1.OwnerReference) MachineBuilder {
+	m.ownerReferences = ownerReferences
+	return m
+}
+
+// WithControllerOwner sets a controller owner reference to the owner for the machine builder.
+// This replaces any owner references previously set on the machine builder.
+func (m MachineBuilder) WithControllerOwner(owner client.Object) MachineBuilder {
+	m.ownerReferences = []metav1.OwnerReference{controllerOwnerReference(owner)}
+	return m
+}
+
+// WithProviderID sets the providerID for the machine builder.
+func (m MachineBuilder) WithProviderID(providerID string) MachineBuilder {
+	m.providerID = &providerID
+	return m
+}
+
 // WithProviderSpecBuilder sets the providerSpec builder for the machine builder.
 func (m MachineBuilder) WithProviderSpecBuilder(builder RawExtensionBuilder) MachineBuilder {
 	m.providerSpecBuilder = builder
@@ -132,6 +181,12 @@ func (m MachineBuilder) WithErrorMessage(errorMsg string) MachineBuilder {
 	return m
 }
 
+// WithErrorReason sets the error reason status field for the machine builder.
+func (m MachineBuilder) WithErrorReason(errorReason machinev1beta1.MachineStatusError) MachineBuilder {
+	m.errorReason = &errorReason
+	return m
+}
+
 // WithPhase sets the phase status field for the machine builder.
 func (m MachineBuilder) WithPhase(phase string) MachineBuilder {
 	m.phase = &phase
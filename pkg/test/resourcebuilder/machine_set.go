@@ -0,0 +1,122 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcebuilder
+
+import (
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MachineSet creates a new machine set builder.
+func MachineSet() MachineSetBuilder {
+	return MachineSetBuilder{
+		replicas: 1,
+	}
+}
+
+// MachineSetBuilder is used to build out a machine set object.
+type MachineSetBuilder struct {
+	generateName        string
+	name                string
+	namespace           string
+	labels              map[string]string
+	replicas            int32
+	providerSpecBuilder RawExtensionBuilder
+}
+
+// Build builds a new machine set based on the configuration provided.
+func (m MachineSetBuilder) Build() *machinev1beta1.MachineSet {
+	machineSet := &machinev1beta1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: m.generateName,
+			Name:         m.name,
+			Namespace:    m.namespace,
+			Labels:       m.labels,
+		},
+		Spec: machinev1beta1.MachineSetSpec{
+			Replicas: &m.replicas,
+			Selector: metav1.LabelSelector{
+				MatchLabels: m.labels,
+			},
+			Template: machinev1beta1.MachineTemplateSpec{
+				ObjectMeta: machinev1beta1.ObjectMeta{
+					Labels: m.labels,
+				},
+			},
+		},
+	}
+
+	if m.providerSpecBuilder != nil {
+		machineSet.Spec.Template.Spec.ProviderSpec.Value = m.providerSpecBuilder.BuildRawExtension()
+	}
+
+	return machineSet
+}
+
+// AsWorker sets the worker role and type on the machine set labels for the machine set builder.
+func (m MachineSetBuilder) AsWorker() MachineSetBuilder {
+	return m.
+		WithLabel(machineRoleLabelName, "worker").
+		WithLabel(machineTypeLabelName, "worker")
+}
+
+// WithGenerateName sets the generateName for the machine set builder.
+func (m MachineSetBuilder) WithGenerateName(generateName string) MachineSetBuilder {
+	m.generateName = generateName
+	return m
+}
+
+// WithLabel sets the labels for the machine set builder.
+func (m MachineSetBuilder) WithLabel(key, value string) MachineSetBuilder {
+	if m.labels == nil {
+		m.labels = make(map[string]string)
+	}
+
+	m.labels[key] = value
+
+	return m
+}
+
+// WithLabels sets the labels for the machine set builder.
+func (m MachineSetBuilder) WithLabels(labels map[string]string) MachineSetBuilder {
+	m.labels = labels
+	return m
+}
+
+// WithName sets the name for the machine set builder.
+func (m MachineSetBuilder) WithName(name string) MachineSetBuilder {
+	m.name = name
+	return m
+}
+
+// WithNamespace sets the namespace for the machine set builder.
+func (m MachineSetBuilder) WithNamespace(namespace string) MachineSetBuilder {
+	m.namespace = namespace
+	return m
+}
+
+// WithProviderSpecBuilder sets the providerSpec builder for the machine set builder.
+func (m MachineSetBuilder) WithProviderSpecBuilder(builder RawExtensionBuilder) MachineSetBuilder {
+	m.providerSpecBuilder = builder
+	return m
+}
+
+// WithReplicas sets the replicas for the machine set builder.
+func (m MachineSetBuilder) WithReplicas(replicas int32) MachineSetBuilder {
+	m.replicas = replicas
+	return m
+}
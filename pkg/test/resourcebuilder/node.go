@@ -36,6 +36,9 @@ type NodeBuilder struct {
 	generateName string
 	name         string
 	labels       map[string]string
+
+	// status fields
+	ready *bool
 }
 
 // Build builds a new node based on the configuration provided.
@@ -48,6 +51,20 @@ func (m NodeBuilder) Build() *corev1.Node {
 		},
 	}
 
+	if m.ready != nil {
+		status := corev1.ConditionFalse
+		if *m.ready {
+			status = corev1.ConditionTrue
+		}
+
+		node.Status.Conditions = []corev1.NodeCondition{
+			{
+				Type:   corev1.NodeReady,
+				Status: status,
+			},
+		}
+	}
+
 	return node
 }
 
@@ -89,3 +106,11 @@ func (m NodeBuilder) WithName(name string) NodeBuilder {
 	m.name = name
 	return m
 }
+
+// Status Fields
+
+// WithReady sets the ready condition status field for the node builder.
+func (m NodeBuilder) WithReady(ready bool) NodeBuilder {
+	m.ready = &ready
+	return m
+}
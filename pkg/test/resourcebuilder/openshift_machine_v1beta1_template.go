@@ -17,6 +17,7 @@ limitations under the License.
 package resourcebuilder
 
 import (
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 )
@@ -36,6 +37,7 @@ func OpenShiftMachineV1Beta1Template() OpenShiftMachineV1Beta1TemplateBuilder {
 type OpenShiftMachineV1Beta1TemplateBuilder struct {
 	failureDomainsBuilder OpenShiftMachineV1Beta1FailureDomainsBuilder
 	labels                map[string]string
+	platformType          configv1.PlatformType
 	providerSpecBuilder   RawExtensionBuilder
 }
 
@@ -54,6 +56,10 @@ func (m OpenShiftMachineV1Beta1TemplateBuilder) BuildTemplate() machinev1.Contro
 		template.OpenShiftMachineV1Beta1Machine.FailureDomains = m.failureDomainsBuilder.BuildFailureDomains()
 	}
 
+	if m.platformType != "" {
+		template.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform = m.platformType
+	}
+
 	if m.providerSpecBuilder != nil {
 		template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = m.providerSpecBuilder.BuildRawExtension()
 	}
@@ -62,6 +68,8 @@ func (m OpenShiftMachineV1Beta1TemplateBuilder) BuildTemplate() machinev1.Contro
 }
 
 // WithFailureDomainsBuilder sets the failure domains builder for the machine template builder.
+// Any platform's failure domains builder may be used, the failure domains platform will be set
+// by the failure domains builder to match the failure domains it builds.
 func (m OpenShiftMachineV1Beta1TemplateBuilder) WithFailureDomainsBuilder(fdsBuilder OpenShiftMachineV1Beta1FailureDomainsBuilder) OpenShiftMachineV1Beta1TemplateBuilder {
 	m.failureDomainsBuilder = fdsBuilder
 	return m
@@ -84,6 +92,14 @@ func (m OpenShiftMachineV1Beta1TemplateBuilder) WithLabels(labels map[string]str
 	return m
 }
 
+// WithPlatformType sets the failure domains platform type for the machine template builder.
+// This overrides the platform type set by the failure domains builder, which allows
+// tests to build templates with an invalid platform type.
+func (m OpenShiftMachineV1Beta1TemplateBuilder) WithPlatformType(platformType configv1.PlatformType) OpenShiftMachineV1Beta1TemplateBuilder {
+	m.platformType = platformType
+	return m
+}
+
 // WithProviderSpecBuilder sets the providerSpec builder for the machine template builder.
 func (m OpenShiftMachineV1Beta1TemplateBuilder) WithProviderSpecBuilder(builder RawExtensionBuilder) OpenShiftMachineV1Beta1TemplateBuilder {
 	m.providerSpecBuilder = builder
@@ -16,6 +16,16 @@ limitations under the License.
 
 package resourcebuilder
 
+import (
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
 // boolPtr returns a pointer to the bool value.
 func boolPtr(b bool) *bool {
 	return &b
@@ -35,3 +45,41 @@ func int64Ptr(i int64) *int64 {
 func stringPtr(s string) *string {
 	return &s
 }
+
+// ownerScheme is used to look up the GroupVersionKind of owners that do not have their TypeMeta populated.
+var ownerScheme = newOwnerScheme() //nolint:gochecknoglobals // This is only read after initialisation.
+
+// newOwnerScheme creates a scheme with the Machine API types installed.
+func newOwnerScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	if err := machinev1.Install(scheme); err != nil {
+		panic(err)
+	}
+
+	if err := machinev1beta1.Install(scheme); err != nil {
+		panic(err)
+	}
+
+	return scheme
+}
+
+// controllerOwnerReference returns a controller owner reference for the owner provided.
+// Typed objects do not always have their TypeMeta populated, so when the owner has no
+// GroupVersionKind set, it is looked up from the Machine API types instead.
+// This panics when the GroupVersionKind of the owner cannot be determined, as the owner
+// reference would otherwise be rejected by the API server.
+func controllerOwnerReference(owner client.Object) metav1.OwnerReference {
+	gvk := owner.GetObjectKind().GroupVersionKind()
+
+	if gvk.Empty() {
+		kinds, _, err := ownerScheme.ObjectKinds(owner)
+		if err != nil {
+			panic(fmt.Sprintf("could not determine the group version kind of owner %s: %v", owner.GetName(), err))
+		}
+
+		gvk = kinds[0]
+	}
+
+	return *metav1.NewControllerRef(owner, gvk)
+}
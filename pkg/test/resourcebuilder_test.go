@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
+)
+
+var _ = Describe("Resource builders", func() {
+	Context("MachineSet", func() {
+		It("should build a MachineSet with three replicas", func() {
+			labels := map[string]string{
+				"machine.openshift.io/cluster-api-machine-role": "worker",
+			}
+
+			machineSet := resourcebuilder.MachineSet().
+				WithName("worker-us-east-1a").
+				WithNamespace("openshift-machine-api").
+				WithLabels(labels).
+				WithReplicas(3).
+				WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).
+				Build()
+
+			Expect(machineSet.Name).To(Equal("worker-us-east-1a"))
+			Expect(machineSet.Namespace).To(Equal("openshift-machine-api"))
+			Expect(machineSet.Spec.Replicas).To(HaveValue(BeEquivalentTo(3)))
+			Expect(machineSet.Spec.Selector.MatchLabels).To(Equal(labels))
+			Expect(machineSet.Spec.Template.ObjectMeta.Labels).To(Equal(labels))
+			Expect(machineSet.Spec.Template.Spec.ProviderSpec.Value).To(Equal(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").BuildRawExtension()))
+		})
+	})
+
+	Context("Node", func() {
+		It("should build a ready master node", func() {
+			node := resourcebuilder.Node().AsMaster().WithName("master-0").WithReady(true).Build()
+
+			Expect(node.GetName()).To(Equal("master-0"))
+			Expect(node.GetLabels()).To(HaveKey("node-role.kubernetes.io/master"))
+			Expect(node.Status.Conditions).To(ConsistOf(SatisfyAll(
+				HaveField("Type", corev1.NodeReady),
+				HaveField("Status", corev1.ConditionTrue),
+			)))
+		})
+	})
+
+	Context("WithControllerOwner", func() {
+		It("should look up the group version kind of an owner without type meta", func() {
+			cpms := resourcebuilder.ControlPlaneMachineSet().WithName("cluster").Build()
+			cpms.TypeMeta = metav1.TypeMeta{}
+
+			machine := resourcebuilder.Machine().WithControllerOwner(cpms).Build()
+
+			Expect(machine.OwnerReferences).To(ConsistOf(SatisfyAll(
+				HaveField("APIVersion", machinev1.GroupVersion.String()),
+				HaveField("Kind", "ControlPlaneMachineSet"),
+				HaveField("Name", "cluster"),
+				HaveField("Controller", HaveValue(BeTrue())),
+			)))
+		})
+
+		It("should panic when the group version kind of the owner cannot be determined", func() {
+			owner := &corev1.ConfigMap{}
+			owner.SetName("unknown")
+
+			Expect(func() {
+				resourcebuilder.Machine().WithControllerOwner(owner)
+			}).To(PanicWith(ContainSubstring("could not determine the group version kind of owner unknown")))
+		})
+	})
+})
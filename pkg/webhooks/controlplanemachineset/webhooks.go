@@ -21,7 +21,10 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
@@ -34,6 +37,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 const (
@@ -45,9 +49,33 @@ const (
 	// This must be present on all OpenShift Machine API Machine templates.
 	openshiftMachineTypeLabel = "machine.openshift.io/cluster-api-machine-type"
 
+	// openshiftMachineSetLabel is the OpenShift Machine API machine set label.
+	// This is set on Machines owned by a MachineSet and must not be present on
+	// control plane Machine templates.
+	openshiftMachineSetLabel = "machine.openshift.io/cluster-api-machineset"
+
+	// openshiftMachineDeploymentLabel is the OpenShift Machine API machine deployment label.
+	// This is set on Machines owned by a MachineDeployment and must not be present on
+	// control plane Machine templates.
+	openshiftMachineDeploymentLabel = "machine.openshift.io/cluster-api-machine-deployment"
+
 	// masterMachineRole is the master role/type that is required to be set on
 	// all OpenShift Machine API Machine templates.
 	masterMachineRole = "master"
+
+	// workerMachineRole is the role set on OpenShift Machine API worker Machines.
+	workerMachineRole = "worker"
+
+	// infrastructureName is the name of the cluster wide Infrastructure singleton.
+	infrastructureName = "cluster"
+
+	// defaultNamespace is the namespace in which the ControlPlaneMachineSet
+	// singleton is expected to be created.
+	defaultNamespace = "openshift-machine-api"
+
+	// validatingWebhookPath is the path on which the ControlPlaneMachineSet validating webhook is served.
+	// This must match the path in the kubebuilder webhook marker below.
+	validatingWebhookPath = "/validate-machine-openshift-io-v1-controlplanemachineset"
 )
 
 var (
@@ -56,27 +84,72 @@ var (
 
 	// errUpdateNilCPMS is an error when update is called with nil ControlPlaneMachineSet.
 	errUpdateNilCPMS = errors.New("cannot update nil control plane machine set")
+
+	// errInvalidSelector is an error when the ControlPlaneMachineSet selector cannot be used
+	// to find the Machines managed by the ControlPlaneMachineSet.
+	errInvalidSelector = errors.New("selector cannot be used to select control plane machines")
 )
 
 // ControlPlaneMachineSetWebhook acts as a webhook validator for the
 // machinev1beta1.ControlPlaneMachineSet resource.
 type ControlPlaneMachineSetWebhook struct {
 	client client.Client
+
+	// Namespace is the namespace in which ControlPlaneMachineSets must be created.
+	// Defaults to openshift-machine-api when empty.
+	Namespace string
+
+	// ReportOnly downgrades all validation failures to admission warnings.
+	// When set, the webhook always admits the request, allowing new checks
+	// to be observed without blocking changes to the ControlPlaneMachineSet.
+	ReportOnly bool
+
+	// AllowZonelessFailureDomains lists the platforms on which failure domains
+	// may be configured without a zone. On AWS, this allows failure domains
+	// that intentionally only specify a subnet.
+	AllowZonelessFailureDomains map[configv1.PlatformType]bool
+
+	// RequireConsistentSubnetReferences rejects AWS failure domains that mix subnet
+	// reference types, for example where some failure domains reference their subnet
+	// by ID and others by filters.
+	RequireConsistentSubnetReferences bool
+
+	// WarnOnMixedInstanceTypes adds an admission warning when the existing control plane
+	// machines do not all use the same instance type.
+	WarnOnMixedInstanceTypes bool
+
+	// WarnOnFailureDomainsWithoutWorkers adds an admission warning when an AWS failure domain
+	// uses an availability zone in which no worker machines exist. Worker machines prove that
+	// an availability zone has usable subnets.
+	WarnOnFailureDomainsWithoutWorkers bool
 }
 
 // SetupWebhookWithManager sets up a new ControlPlaneMachineSet webhook with the manager.
 func (r *ControlPlaneMachineSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	r.client = mgr.GetClient()
-	if err := ctrl.NewWebhookManagedBy(mgr).
-		WithValidator(r).
-		For(&machinev1.ControlPlaneMachineSet{}).
-		Complete(); err != nil {
-		return fmt.Errorf("error constructing ControlPlaneMachineSet webhook: %w", err)
+	r.Namespace = r.namespace()
+
+	vwh := admission.WithCustomValidator(&machinev1.ControlPlaneMachineSet{}, r)
+	vwh.Handler = &warningHandler{handler: vwh.Handler, warnings: r.warnings}
+
+	if r.ReportOnly {
+		vwh.Handler = &reportOnlyHandler{handler: vwh.Handler}
 	}
 
+	mgr.GetWebhookServer().Register(validatingWebhookPath, vwh)
+
 	return nil
 }
 
+// namespace returns the namespace in which ControlPlaneMachineSets must be created.
+func (r *ControlPlaneMachineSetWebhook) namespace() string {
+	if r.Namespace == "" {
+		return defaultNamespace
+	}
+
+	return r.Namespace
+}
+
 //+kubebuilder:webhook:verbs=create;update,path=/validate-machine-openshift-io-v1-controlplanemachineset,mutating=false,failurePolicy=fail,groups=machine.openshift.io,resources=controlplanemachinesets,versions=v1,name=controlplanemachineset.machine.openshift.io,sideEffects=None,admissionReviewVersions=v1
 
 var _ webhook.CustomValidator = &ControlPlaneMachineSetWebhook{}
@@ -90,31 +163,72 @@ func (r *ControlPlaneMachineSetWebhook) ValidateCreate(ctx context.Context, obj
 		return errObjNotCPMS
 	}
 
-	controlPlaneMachines, err := r.fetchControlPlaneMachines(ctx)
-	if err != nil {
+	// When the selector is invalid, the checks based on the existing machines are skipped,
+	// as the selector error is reported by checkMachineLabels.
+	selectedMachines, err := r.fetchSelectedMachines(ctx, cpms)
+	validSelector := !errors.Is(err, errInvalidSelector)
+
+	if err != nil && validSelector {
 		return fmt.Errorf("could not fetch existing control plane machines: %w", err)
 	}
 
+	controlPlaneMachines := filterControlPlaneMachines(selectedMachines)
+
 	// Ensure Control Plane Machine count matches the ControlPlaneMachineSet replicas
 	if cpms.Spec.Replicas == nil {
 		errs = append(errs, field.Required(field.NewPath("spec", "replicas"), "replicas field is required"))
-	} else if int(*cpms.Spec.Replicas) != len(controlPlaneMachines) {
+	} else if validSelector && int(*cpms.Spec.Replicas) != len(controlPlaneMachines) {
 		errs = append(errs, field.Forbidden(field.NewPath("spec", "replicas"),
 			fmt.Sprintf("control plane machine set replicas (%d) does not match the current number of control plane machines (%d)", *cpms.Spec.Replicas, len(controlPlaneMachines))))
 	}
 
-	// Ensure CPMS created with invalid name is not allowed
-	if cpms.Name != "cluster" {
+	// Ensure CPMS created outside of the operator namespace is not allowed.
+	// The singleton name only applies within the operator namespace, so only check the name
+	// once the namespace is valid, to avoid reporting a misleading name error.
+	if namespace := r.namespace(); cpms.Namespace != namespace {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "namespace"), cpms.Namespace,
+			fmt.Sprintf("control plane machine set must be created in the %s namespace", namespace)))
+	} else if cpms.Name != "cluster" {
+		// Ensure CPMS created with invalid name is not allowed
 		errs = append(errs, field.Invalid(field.NewPath("name"), cpms.Name, "control plane machine set name must be cluster"))
 	}
 
+	// Ensure CPMS created with a status is not allowed, the status is managed by the controller.
+	// The API server drops the status on create as the status subresource is enabled,
+	// so this only rejects objects that are passed to the webhook directly.
+	if !reflect.DeepEqual(cpms.Status, machinev1.ControlPlaneMachineSetStatus{}) {
+		errs = append(errs, field.Forbidden(field.NewPath("status"), "status must not be set on create"))
+	}
+
+	infrastructure, err := r.fetchInfrastructure(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch cluster infrastructure: %w", err)
+	}
+
 	// Ensure required labels are set and all machines are matching the label selector
-	errs = append(errs, checkMachineLabels(cpms)...)
+	errs = append(errs, checkMachineLabels(cpms, infrastructure)...)
+
+	// Ensure the selector does not match any machines outside of the control plane
+	if validSelector {
+		errs = append(errs, checkSelectedMachineRoles(selectedMachines)...)
+	}
+
+	// Ensure the selector only uses equality based label matching
+	errs = append(errs, checkSelectorMatchLabelsOnly(cpms)...)
 
 	// Ensure failure domains of Control Plane Machines match the ControlPlaneMachineSet on create
 	switch cpms.Spec.Template.MachineType {
 	case machinev1.OpenShiftMachineV1Beta1MachineType:
-		errs = append(errs, checkFailureDomains(cpms, controlPlaneMachines)...)
+		if validSelector {
+			errs = append(errs, checkFailureDomains(cpms, controlPlaneMachines)...)
+		}
+
+		errs = append(errs, checkFailureDomainsNotEmpty(cpms)...)
+		errs = append(errs, checkFailureDomainCount(cpms)...)
+		errs = append(errs, r.checkFailureDomainZones(cpms)...)
+		errs = append(errs, r.checkFailureDomainSubnetReferences(cpms)...)
+		errs = append(errs, checkFailureDomainPlatforms(cpms)...)
+		errs = append(errs, checkProviderConfig(cpms)...)
 	default:
 		errs = append(errs, field.NotSupported(field.NewPath("spec", "template", "machineType"), cpms.Spec.Template.MachineType,
 			[]string{string(machinev1.OpenShiftMachineV1Beta1MachineType)}))
@@ -149,7 +263,8 @@ func (r *ControlPlaneMachineSetWebhook) ValidateUpdate(ctx context.Context, oldO
 	if oldCPMS.Spec.Replicas == nil || newCPMS.Spec.Replicas == nil {
 		errs = append(errs, field.Required(field.NewPath("spec", "replicas"), "replicas field is required"))
 	} else if *oldCPMS.Spec.Replicas != *newCPMS.Spec.Replicas {
-		errs = append(errs, field.Forbidden(field.NewPath("spec", "replicas"), "control plane machine set replicas cannot be changed"))
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "replicas"),
+			fmt.Sprintf("control plane machine set replicas cannot be changed from %d to %d", *oldCPMS.Spec.Replicas, *newCPMS.Spec.Replicas)))
 	}
 
 	// Ensure selector is immutable on update
@@ -157,8 +272,47 @@ func (r *ControlPlaneMachineSetWebhook) ValidateUpdate(ctx context.Context, oldO
 		errs = append(errs, field.Forbidden(field.NewPath("spec", "selector"), "control plane machine set selector is immutable"))
 	}
 
+	// Ensure the failure domains platform is immutable on update
+	errs = append(errs, checkFailureDomainsPlatformImmutable(oldCPMS, newCPMS)...)
+
+	infrastructure, err := r.fetchInfrastructure(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch cluster infrastructure: %w", err)
+	}
+
 	// Ensure required labels are set and all machines are matching the label selector
-	errs = append(errs, checkMachineLabels(newCPMS)...)
+	errs = append(errs, checkMachineLabels(newCPMS, infrastructure)...)
+
+	// Ensure there are enough failure domains to spread the control plane machines across
+	errs = append(errs, checkFailureDomainsNotEmpty(newCPMS)...)
+	errs = append(errs, checkFailureDomainCount(newCPMS)...)
+
+	// Ensure each failure domain specifies a zone where the platform requires one
+	errs = append(errs, r.checkFailureDomainZones(newCPMS)...)
+	errs = append(errs, r.checkFailureDomainSubnetReferences(newCPMS)...)
+
+	// Ensure every failure domain belongs to the same platform as the provider spec
+	errs = append(errs, checkFailureDomainPlatforms(newCPMS)...)
+
+	// When the selector is invalid, the checks based on the existing machines are skipped,
+	// as the selector error is reported by checkMachineLabels.
+	selectedMachines, err := r.fetchSelectedMachines(ctx, newCPMS)
+	validSelector := !errors.Is(err, errInvalidSelector)
+
+	if err != nil && validSelector {
+		return fmt.Errorf("could not fetch existing control plane machines: %w", err)
+	}
+
+	// Ensure failure domains are not removed while control plane machines are still using them
+	if validSelector {
+		errs = append(errs, checkRemovedFailureDomains(oldCPMS, newCPMS, filterControlPlaneMachines(selectedMachines))...)
+	}
+
+	// Ensure the platform type of the provider spec is not changed on update
+	errs = append(errs, checkProviderSpecPlatformType(oldCPMS, newCPMS)...)
+
+	// Ensure the provider spec can be decoded and is valid for its platform
+	errs = append(errs, checkProviderConfig(newCPMS)...)
 
 	if len(errs) > 0 {
 		return utilerrors.NewAggregate(errs)
@@ -172,26 +326,124 @@ func (r *ControlPlaneMachineSetWebhook) ValidateDelete(ctx context.Context, obj
 	return nil
 }
 
-// fetchControlPlaneMachines returns all control plane machines in the cluster.
-func (r *ControlPlaneMachineSetWebhook) fetchControlPlaneMachines(ctx context.Context) ([]machinev1beta1.Machine, error) {
-	machineList := machinev1beta1.MachineList{}
-	if err := r.client.List(ctx, &machineList); err != nil {
-		return nil, fmt.Errorf("error querying api for machines: %w", err)
+// warningHandler wraps an admission handler and adds any warnings for the
+// ControlPlaneMachineSet in the request to the response.
+type warningHandler struct {
+	handler  admission.Handler
+	decoder  *admission.Decoder
+	warnings func(context.Context, *machinev1.ControlPlaneMachineSet) []string
+}
+
+var _ admission.DecoderInjector = &warningHandler{}
+
+// InjectDecoder injects the decoder into the handler and the wrapped handler.
+func (h *warningHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+
+	if _, err := admission.InjectDecoderInto(d, h.handler); err != nil {
+		return fmt.Errorf("could not inject decoder into warning handler: %w", err)
+	}
+
+	return nil
+}
+
+// Handle handles the admission request using the wrapped handler and
+// adds the warnings for the ControlPlaneMachineSet to the response.
+func (h *warningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.handler.Handle(ctx, req)
+
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	if err := h.decoder.Decode(req, cpms); err != nil {
+		// Objects that cannot be decoded are reported by the wrapped handler.
+		return resp
+	}
+
+	return resp.WithWarnings(h.warnings(ctx, cpms)...)
+}
+
+// reportOnlyHandler wraps an admission handler and converts any denied
+// response into an allowed response, surfacing the denial reason as a warning.
+type reportOnlyHandler struct {
+	handler admission.Handler
+}
+
+var _ admission.DecoderInjector = &reportOnlyHandler{}
+
+// InjectDecoder injects the decoder into the wrapped handler.
+func (h *reportOnlyHandler) InjectDecoder(d *admission.Decoder) error {
+	if _, err := admission.InjectDecoderInto(d, h.handler); err != nil {
+		return fmt.Errorf("could not inject decoder into report only handler: %w", err)
+	}
+
+	return nil
+}
+
+// Handle handles the admission request using the wrapped handler and
+// admits the request regardless of the outcome of the validation.
+func (h *reportOnlyHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.handler.Handle(ctx, req)
+	if resp.Allowed {
+		return resp
 	}
 
+	warnings := resp.Warnings
+	if resp.Result != nil && resp.Result.Message != "" {
+		warnings = append(warnings, resp.Result.Message)
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// filterControlPlaneMachines returns the control plane machines from the list of machines.
+func filterControlPlaneMachines(machines []machinev1beta1.Machine) []machinev1beta1.Machine {
 	controlPlaneMachines := []machinev1beta1.Machine{}
 
-	for _, machine := range machineList.Items {
+	for _, machine := range machines {
 		if machine.Labels[openshiftMachineRoleLabel] == masterMachineRole && machine.Labels[openshiftMachineTypeLabel] == masterMachineRole {
 			controlPlaneMachines = append(controlPlaneMachines, machine)
 		}
 	}
 
-	return controlPlaneMachines, nil
+	return controlPlaneMachines
+}
+
+// fetchSelectedMachines returns all Machines within the ControlPlaneMachineSet namespace
+// that match the ControlPlaneMachineSet selector.
+// The selector is passed to the API so that Machines outside of the selector are never fetched.
+// When the selector cannot be parsed, or does not match the labels of the machine template,
+// errInvalidSelector is returned as the selected Machines are not the Machines that the
+// ControlPlaneMachineSet would manage.
+func (r *ControlPlaneMachineSetWebhook) fetchSelectedMachines(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) ([]machinev1beta1.Machine, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSelector, err)
+	}
+
+	if template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine; template != nil && !selector.Matches(labels.Set(template.ObjectMeta.Labels)) {
+		return nil, fmt.Errorf("%w: selector does not match template labels", errInvalidSelector)
+	}
+
+	machineList := machinev1beta1.MachineList{}
+	if err := r.client.List(ctx, &machineList, client.InNamespace(cpms.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("error querying api for machines: %w", err)
+	}
+
+	return machineList.Items, nil
+}
+
+// fetchInfrastructure returns the cluster wide Infrastructure object.
+func (r *ControlPlaneMachineSetWebhook) fetchInfrastructure(ctx context.Context) (*configv1.Infrastructure, error) {
+	infrastructure := &configv1.Infrastructure{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: infrastructureName}, infrastructure); err != nil {
+		return nil, fmt.Errorf("error querying api for infrastructure: %w", err)
+	}
+
+	return infrastructure, nil
 }
 
 // checkMachineLabels ensures that required labels are set and all machines are matching the label selector.
-func checkMachineLabels(cpms *machinev1.ControlPlaneMachineSet) []error {
+// The cluster ID label must also match the infrastructure name of the cluster.
+func checkMachineLabels(cpms *machinev1.ControlPlaneMachineSet, infrastructure *configv1.Infrastructure) []error {
 	machineTemplatePath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io")
 	errs := []error{}
 
@@ -204,6 +456,21 @@ func checkMachineLabels(cpms *machinev1.ControlPlaneMachineSet) []error {
 		}
 	}
 
+	// Ensure machine template does not contain labels reserved for machines owned by other controllers
+	forbiddenLabels := []string{openshiftMachineSetLabel, openshiftMachineDeploymentLabel}
+	for _, label := range forbiddenLabels {
+		if _, ok := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels[label]; ok {
+			errs = append(errs, field.Forbidden(machineTemplatePath.Child("metadata", "labels"), fmt.Sprintf("label %s is not allowed on control plane machines", label)))
+		}
+	}
+
+	// Ensure the cluster ID label matches the cluster the machines will be created in
+	clusterID, ok := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels[machinev1beta1.MachineClusterIDLabel]
+	if ok && clusterID != "" && clusterID != infrastructure.Status.InfrastructureName {
+		errs = append(errs, field.Invalid(machineTemplatePath.Child("metadata", "labels"), clusterID,
+			fmt.Sprintf("must match cluster infrastructure name %q", infrastructure.Status.InfrastructureName)))
+	}
+
 	// Ensure machines are matched by selectors
 	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
 	if err != nil {
@@ -218,6 +485,117 @@ func checkMachineLabels(cpms *machinev1.ControlPlaneMachineSet) []error {
 	return errs
 }
 
+// checkSelectedMachineRoles ensures that all machines matched by the selector are control plane machines.
+func checkSelectedMachineRoles(machines []machinev1beta1.Machine) []error {
+	nonControlPlaneMachines := []string{}
+
+	for _, machine := range machines {
+		if machine.Labels[openshiftMachineRoleLabel] != masterMachineRole {
+			nonControlPlaneMachines = append(nonControlPlaneMachines, machine.Name)
+		}
+	}
+
+	if len(nonControlPlaneMachines) > 0 {
+		return []error{field.Forbidden(field.NewPath("spec", "selector"), fmt.Sprintf("selector matches non-control-plane machines %v", nonControlPlaneMachines))}
+	}
+
+	return nil
+}
+
+// checkSelectorMatchLabelsOnly ensures that the selector does not use match expressions.
+// Control plane machines are matched on simple label equality, set based requirements are not supported.
+func checkSelectorMatchLabelsOnly(cpms *machinev1.ControlPlaneMachineSet) []error {
+	if len(cpms.Spec.Selector.MatchExpressions) > 0 {
+		return []error{field.Forbidden(field.NewPath("spec", "selector"), "matchExpressions are not supported, use matchLabels")}
+	}
+
+	return nil
+}
+
+// checkProviderConfig ensures that the provider spec within the machine template can be decoded
+// and passes the validation rules for its platform.
+// Provider specs for platforms that are not yet supported by the provider config are not checked.
+func checkProviderConfig(cpms *machinev1.ControlPlaneMachineSet) []error {
+	providerSpecPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "spec", "providerSpec")
+
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil || template.Spec.ProviderSpec.Value == nil {
+		return nil
+	}
+
+	providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(*template)
+	if err != nil {
+		if providerconfig.IsUnsupportedPlatformError(err) {
+			return nil
+		}
+
+		return []error{field.Invalid(providerSpecPath, err.Error(), "provider spec could not be decoded")}
+	}
+
+	errs := []error{}
+
+	for _, fieldErr := range providerConfig.Validate() {
+		// The provider config reports paths relative to the provider spec value.
+		fieldErr.Field = providerSpecPath.Child("value", fieldErr.Field).String()
+		errs = append(errs, fieldErr)
+	}
+
+	return errs
+}
+
+// checkFailureDomainsPlatformImmutable ensures that the failure domains platform has not been changed between
+// the old and new ControlPlaneMachineSet. Failure domains may be added to, or removed from, a ControlPlaneMachineSet
+// without failure domains, so the platform may be set or cleared, but it may not be changed to another platform.
+func checkFailureDomainsPlatformImmutable(oldCPMS, newCPMS *machinev1.ControlPlaneMachineSet) []error {
+	platformPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains", "platform")
+
+	oldTemplate := oldCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine
+	newTemplate := newCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine
+
+	if oldTemplate == nil || newTemplate == nil {
+		return nil
+	}
+
+	oldPlatform := oldTemplate.FailureDomains.Platform
+	newPlatform := newTemplate.FailureDomains.Platform
+
+	if oldPlatform != "" && newPlatform != "" && oldPlatform != newPlatform {
+		return []error{field.Forbidden(platformPath, "failure domains platform is immutable")}
+	}
+
+	return nil
+}
+
+// checkProviderSpecPlatformType ensures that the platform type of the provider spec within the machine template
+// has not been changed between the old and new ControlPlaneMachineSet.
+func checkProviderSpecPlatformType(oldCPMS, newCPMS *machinev1.ControlPlaneMachineSet) []error {
+	providerSpecPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "spec", "providerSpec")
+
+	oldTemplate := oldCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine
+	newTemplate := newCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine
+
+	if oldTemplate == nil || newTemplate == nil || oldTemplate.Spec.ProviderSpec.Value == nil || newTemplate.Spec.ProviderSpec.Value == nil {
+		return nil
+	}
+
+	oldPlatformType, err := providerconfig.PlatformTypeFromProviderSpec(oldTemplate.Spec.ProviderSpec)
+	if err != nil {
+		// The existing provider spec cannot be inspected so there is nothing to compare against.
+		return nil
+	}
+
+	newPlatformType, err := providerconfig.PlatformTypeFromProviderSpec(newTemplate.Spec.ProviderSpec)
+	if err != nil {
+		return []error{field.Invalid(providerSpecPath, newTemplate.Spec.ProviderSpec, fmt.Sprintf("could not determine platform type: %v", err))}
+	}
+
+	if oldPlatformType != newPlatformType {
+		return []error{field.Forbidden(providerSpecPath, fmt.Sprintf("platform type cannot be changed from %s to %s", oldPlatformType, newPlatformType))}
+	}
+
+	return nil
+}
+
 // checkFailureDomains ensures that failure domains of Control Plane Machines match the ControlPlaneMachineSet.
 func checkFailureDomains(cpms *machinev1.ControlPlaneMachineSet, controlPlaneMachines []machinev1beta1.Machine) []error {
 	machineTemplatePath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io")
@@ -245,37 +623,490 @@ func checkFailureDomains(cpms *machinev1.ControlPlaneMachineSet, controlPlaneMac
 			fmt.Sprintf("error getting failure domains from control plane machine set machine template: %v", err)))
 	}
 
+	missingFromMachines, missingFromDeclared := providerconfig.ValidateFailureDomainCoverage(specifiedFailureDomains, machineFailureDomains)
+
 	// Failure domains used by control plane machines but not specified in the control plane machine set
-	if missingFailureDomains := missingFailureDomains(machineFailureDomains, specifiedFailureDomains); len(missingFailureDomains) > 0 {
-		errs = append(errs, field.Forbidden(machineTemplatePath.Child("failureDomains"), fmt.Sprintf("control plane machines are using unspecified failure domain(s) %s", missingFailureDomains)))
+	if len(missingFromDeclared) > 0 {
+		errs = append(errs, field.Forbidden(machineTemplatePath.Child("failureDomains"), fmt.Sprintf("control plane machines are using unspecified failure domain(s) %s", missingFromDeclared)))
 	}
 
 	// Failure domains specified in the control plane machine set but not used by control plane machines
-	if missingFailureDomains := missingFailureDomains(specifiedFailureDomains, machineFailureDomains); len(missingFailureDomains) > 0 {
-		errs = append(errs, field.Forbidden(machineTemplatePath.Child("failureDomains"), fmt.Sprintf("no control plane machine is using specified failure domain(s) %s", missingFailureDomains)))
+	if len(missingFromMachines) > 0 {
+		errs = append(errs, field.Forbidden(machineTemplatePath.Child("failureDomains"), fmt.Sprintf("no control plane machine is using specified failure domain(s) %s", missingFromMachines)))
+	}
+
+	return errs
+}
+
+// checkRemovedFailureDomains ensures that failure domains removed from the ControlPlaneMachineSet
+// on update are not still in use by any of the control plane machines.
+// Machines are correlated to failure domains using FailureDomain.Matches, and a machine is only
+// considered orphaned when it does not match any of the remaining failure domains.
+func checkRemovedFailureDomains(oldCPMS, newCPMS *machinev1.ControlPlaneMachineSet, controlPlaneMachines []machinev1beta1.Machine) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	if oldCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine == nil || newCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+		return nil
+	}
+
+	oldFailureDomains, err := failuredomain.NewFailureDomains(oldCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains)
+	if err != nil {
+		// The existing failure domains were already admitted, nothing can be compared.
+		return nil
+	}
+
+	newFailureDomains, err := failuredomain.NewFailureDomains(newCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains)
+	if err != nil {
+		return []error{field.Invalid(failureDomainsPath, newCPMS.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains,
+			fmt.Sprintf("error getting failure domains from control plane machine set machine template: %v", err))}
+	}
+
+	removedFailureDomains := failuredomain.Difference(oldFailureDomains, newFailureDomains)
+	if len(removedFailureDomains) == 0 {
+		return nil
+	}
+
+	machineProviderConfigs := map[string]providerconfig.ProviderConfig{}
+
+	for _, machine := range controlPlaneMachines {
+		providerConfig, err := providerconfig.NewProviderConfigFromMachine(machine)
+		if err != nil {
+			// Machines with unparseable provider specs cannot be correlated to a failure domain.
+			continue
+		}
+
+		machineProviderConfigs[machine.Name] = providerConfig
+	}
+
+	errs := []error{}
+
+	for _, removed := range removedFailureDomains {
+		orphanedMachines := []string{}
+
+		for name, providerConfig := range machineProviderConfigs {
+			if failureDomainsMatch([]failuredomain.FailureDomain{removed}, providerConfig) && !failureDomainsMatch(newFailureDomains, providerConfig) {
+				orphanedMachines = append(orphanedMachines, name)
+			}
+		}
+
+		if len(orphanedMachines) > 0 {
+			sort.Strings(orphanedMachines)
+
+			errs = append(errs, field.Forbidden(failureDomainsPath, fmt.Sprintf("removing failure domain %s would orphan machines %v", failureDomainName(removed), orphanedMachines)))
+		}
+	}
+
+	return errs
+}
+
+// failureDomainsMatch returns true when any of the failure domains matches the failure domain
+// of the provider config. Failure domains from a different platform do not match.
+func failureDomainsMatch(failureDomains []failuredomain.FailureDomain, providerConfig providerconfig.ProviderConfig) bool {
+	for _, fd := range failureDomains {
+		if matches, err := fd.Matches(providerConfig); err == nil && matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failureDomainName returns a short name for the failure domain for use in messages.
+// This is the zone of the failure domain, or the full representation of the failure
+// domain when it does not specify a zone.
+func failureDomainName(fd failuredomain.FailureDomain) string {
+	var zone string
+
+	switch fd.Type() {
+	case configv1.AWSPlatformType:
+		zone = fd.AWS().Placement.AvailabilityZone
+	case configv1.AzurePlatformType:
+		zone = fd.Azure().Zone
+	case configv1.GCPPlatformType:
+		zone = fd.GCP().Zone
+	case configv1.OpenStackPlatformType:
+		zone = fd.OpenStack().AvailabilityZone
+	}
+
+	if zone == "" {
+		return fd.String()
+	}
+
+	return zone
+}
+
+// warnings returns admission warnings for configuration that is allowed but is likely to be a mistake.
+func (r *ControlPlaneMachineSetWebhook) warnings(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) []string {
+	warnings := []string{}
+
+	if cpms.Spec.Template.MachineType == machinev1.OpenShiftMachineV1Beta1MachineType {
+		warnings = append(warnings, warnOverriddenProviderSpecZone(cpms)...)
+
+		if r.WarnOnMixedInstanceTypes {
+			warnings = append(warnings, r.warnMixedInstanceTypes(ctx, cpms)...)
+		}
+
+		if r.WarnOnFailureDomainsWithoutWorkers {
+			warnings = append(warnings, r.warnFailureDomainsWithoutWorkers(ctx, cpms)...)
+		}
+	}
+
+	return warnings
+}
+
+// warnMixedInstanceTypes warns when the control plane machines selected by the ControlPlaneMachineSet
+// use different instance types. Failure domains do not configure the instance type, so any difference
+// between the machines is not explained by the failure domains. Machines whose provider spec cannot be
+// decoded are ignored.
+func (r *ControlPlaneMachineSetWebhook) warnMixedInstanceTypes(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) []string {
+	selectedMachines, err := r.fetchSelectedMachines(ctx, cpms)
+	if err != nil {
+		// Errors fetching machines are reported by the validation.
+		return nil
+	}
+
+	machinesByInstanceType := map[string][]string{}
+
+	for _, machine := range filterControlPlaneMachines(selectedMachines) {
+		providerConfig, err := providerconfig.NewProviderConfigFromMachine(machine)
+		if err != nil {
+			continue
+		}
+
+		instanceType := providerConfig.InstanceType()
+		machinesByInstanceType[instanceType] = append(machinesByInstanceType[instanceType], machine.Name)
+	}
+
+	if len(machinesByInstanceType) < 2 {
+		return nil
+	}
+
+	instanceTypes := []string{}
+
+	for instanceType, machineNames := range machinesByInstanceType {
+		sort.Strings(machineNames)
+		instanceTypes = append(instanceTypes, fmt.Sprintf("%s %v", instanceType, machineNames))
+	}
+
+	sort.Strings(instanceTypes)
+
+	return []string{fmt.Sprintf("control plane machines use different instance types: %s", strings.Join(instanceTypes, ", "))}
+}
+
+// warnFailureDomainsWithoutWorkers warns when an AWS failure domain uses an availability zone in which
+// no worker machines exist, as the availability zone may not have any usable subnets.
+// When there are no worker machines, for example on compact clusters, no availability zones can be
+// confirmed and so no warnings are returned. Machines whose provider spec cannot be decoded are ignored.
+func (r *ControlPlaneMachineSetWebhook) warnFailureDomainsWithoutWorkers(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) []string {
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil || template.FailureDomains.Platform != configv1.AWSPlatformType || template.FailureDomains.AWS == nil {
+		return nil
+	}
+
+	machineList := machinev1beta1.MachineList{}
+	if err := r.client.List(ctx, &machineList, client.InNamespace(cpms.Namespace), client.MatchingLabels{openshiftMachineRoleLabel: workerMachineRole}); err != nil {
+		return nil
+	}
+
+	workerZones := map[string]bool{}
+
+	for _, machine := range machineList.Items {
+		providerConfig, err := providerconfig.NewProviderConfigFromMachine(machine)
+		if err != nil {
+			continue
+		}
+
+		workerZones[providerConfig.Zone()] = true
+	}
+
+	if len(workerZones) == 0 {
+		return nil
+	}
+
+	warnings := []string{}
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	for i, fd := range *template.FailureDomains.AWS {
+		zone := fd.Placement.AvailabilityZone
+		if zone == "" || workerZones[zone] {
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf("%s: no worker machines use the availability zone %s",
+			failureDomainsPath.Child("aws").Index(i).Child("placement", "availabilityZone"), zone))
+	}
+
+	return warnings
+}
+
+// warnOverriddenProviderSpecZone warns when the template provider spec configures a zone
+// while failure domains are configured, as the zone is replaced by the zone of each failure domain.
+func warnOverriddenProviderSpecZone(cpms *machinev1.ControlPlaneMachineSet) []string {
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil {
+		return nil
+	}
+
+	failureDomains, err := failuredomain.NewFailureDomains(template.FailureDomains)
+	if err != nil || len(failureDomains) == 0 {
+		return nil
+	}
+
+	providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(*template)
+	if err != nil {
+		// Invalid provider specs are reported by checkProviderConfig.
+		return nil
+	}
+
+	if zone := providerConfig.Zone(); zone != "" {
+		providerSpecPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "spec", "providerSpec")
+
+		return []string{fmt.Sprintf("%s: the provider spec zone %s will be overridden by the failure domains", providerSpecPath.String(), zone)}
+	}
+
+	return nil
+}
+
+// checkFailureDomainsNotEmpty ensures that at least one failure domain is specified when the failure domains
+// platform is set. Omitting the failure domains entirely remains valid.
+func checkFailureDomainsNotEmpty(cpms *machinev1.ControlPlaneMachineSet) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+		return nil
+	}
+
+	if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform == "" {
+		return nil
+	}
+
+	failureDomains, err := failuredomain.NewFailureDomains(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains)
+	if err != nil {
+		// Invalid failure domains are reported by checkFailureDomains.
+		return nil
+	}
+
+	if len(failureDomains) == 0 {
+		return []error{field.Required(failureDomainsPath, "at least one failure domain must be specified when platform is set")}
+	}
+
+	return nil
+}
+
+// checkFailureDomainCount ensures that enough distinct failure domains are specified to maintain etcd quorum
+// in the event of a failure domain outage.
+// A 5 replica control plane requires at least 3 failure domains.
+// Empty failure domain lists are reported by checkFailureDomainsNotEmpty.
+func checkFailureDomainCount(cpms *machinev1.ControlPlaneMachineSet) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	if cpms.Spec.Replicas == nil || cpms.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+		return nil
+	}
+
+	if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform == "" {
+		return nil
+	}
+
+	failureDomains, err := failuredomain.NewFailureDomains(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains)
+	if err != nil {
+		// Invalid failure domains are reported by checkFailureDomains.
+		return nil
+	}
+
+	if len(failureDomains) == 0 {
+		return nil
+	}
+
+	var minimumFailureDomains int
+
+	switch *cpms.Spec.Replicas {
+	case 5:
+		minimumFailureDomains = 3
+	default:
+		return nil
+	}
+
+	if distinct := len(failuredomain.Distinct(failureDomains)); distinct < minimumFailureDomains {
+		return []error{field.Forbidden(failureDomainsPath, fmt.Sprintf("at least %d failure domains are required for %d control plane machines", minimumFailureDomains, *cpms.Spec.Replicas))}
+	}
+
+	return nil
+}
+
+// checkFailureDomainPlatforms ensures that the failure domains platform matches the platform of the
+// provider spec in the machine template, and that only the failure domains for that platform are configured.
+func checkFailureDomainPlatforms(cpms *machinev1.ControlPlaneMachineSet) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil || template.Spec.ProviderSpec.Value == nil {
+		return nil
+	}
+
+	platformType, err := providerconfig.PlatformTypeFromProviderSpec(template.Spec.ProviderSpec)
+	if err != nil {
+		// Provider specs that cannot be decoded are reported by checkProviderConfig.
+		return nil
+	}
+
+	errs := []error{}
+
+	failureDomains := template.FailureDomains
+	if failureDomains.Platform != "" && failureDomains.Platform != platformType {
+		errs = append(errs, field.Invalid(failureDomainsPath.Child("platform"), failureDomains.Platform,
+			fmt.Sprintf("failure domains platform must match provider spec platform %s", platformType)))
+	}
+
+	// Without a failure domains platform, the failure domains are expected to match the provider spec.
+	expectedPlatform := failureDomains.Platform
+	if expectedPlatform == "" {
+		expectedPlatform = platformType
+	}
+
+	if failureDomains.AWS != nil && expectedPlatform != configv1.AWSPlatformType {
+		for i, fd := range *failureDomains.AWS {
+			errs = append(errs, failureDomainPlatformMismatch(failureDomainsPath.Child("aws").Index(i), failuredomain.NewAWSFailureDomain(fd), expectedPlatform))
+		}
+	}
+
+	if failureDomains.Azure != nil && expectedPlatform != configv1.AzurePlatformType {
+		for i, fd := range *failureDomains.Azure {
+			errs = append(errs, failureDomainPlatformMismatch(failureDomainsPath.Child("azure").Index(i), failuredomain.NewAzureFailureDomain(fd), expectedPlatform))
+		}
+	}
+
+	if failureDomains.GCP != nil && expectedPlatform != configv1.GCPPlatformType {
+		for i, fd := range *failureDomains.GCP {
+			errs = append(errs, failureDomainPlatformMismatch(failureDomainsPath.Child("gcp").Index(i), failuredomain.NewGCPFailureDomain(fd), expectedPlatform))
+		}
+	}
+
+	if failureDomains.OpenStack != nil && expectedPlatform != configv1.OpenStackPlatformType {
+		for i, fd := range *failureDomains.OpenStack {
+			errs = append(errs, failureDomainPlatformMismatch(failureDomainsPath.Child("openstack").Index(i), failuredomain.NewOpenStackFailureDomain(fd), expectedPlatform))
+		}
 	}
 
 	return errs
 }
 
-// missingFailureDomains returns failure domains from list1 that are not in list2.
-func missingFailureDomains(list1 []failuredomain.FailureDomain, list2 []failuredomain.FailureDomain) []failuredomain.FailureDomain {
-	missing := []failuredomain.FailureDomain{}
+// failureDomainPlatformMismatch returns an error for a failure domain configured for a platform
+// other than the failure domains platform.
+func failureDomainPlatformMismatch(path *field.Path, fd failuredomain.FailureDomain, platform configv1.PlatformType) error {
+	return field.Invalid(path, fd.String(), fmt.Sprintf("failure domain platform %s does not match failure domains platform %s", fd.Type(), platform))
+}
+
+// checkFailureDomainZones ensures that each failure domain specifies a zone on platforms where
+// the zone is expected. Azure allows failure domains without a zone for regions without
+// availability zones, so it is not checked. Other platforms may be exempted by
+// configuring AllowZonelessFailureDomains on the webhook. On AWS, the exemption allows
+// failure domains that only specify a subnet, but a failure domain must still specify
+// either an availability zone or a subnet.
+func (r *ControlPlaneMachineSetWebhook) checkFailureDomainZones(cpms *machinev1.ControlPlaneMachineSet) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+		return nil
+	}
+
+	failureDomains := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains
+	if r.AllowZonelessFailureDomains[failureDomains.Platform] {
+		return checkZonelessAWSFailureDomains(failureDomainsPath, failureDomains)
+	}
+
+	errs := []error{}
+
+	switch failureDomains.Platform {
+	case configv1.AWSPlatformType:
+		if failureDomains.AWS == nil {
+			return nil
+		}
 
-	for _, outerItem := range list1 {
-		found := false
+		for i, fd := range *failureDomains.AWS {
+			zonePath := failureDomainsPath.Child("aws").Index(i).Child("placement", "availabilityZone")
 
-		for _, innerItem := range list2 {
-			if outerItem.Equal(innerItem) {
-				found = true
-				break
+			if err := failuredomain.ValidateAWS(failuredomain.NewAWSFailureDomain(fd)); err != nil {
+				errs = append(errs, field.Required(zonePath, "an availability zone must be specified when a subnet is specified"))
+			} else if fd.Placement.AvailabilityZone == "" {
+				errs = append(errs, field.Required(zonePath, "an availability zone must be specified"))
 			}
 		}
+	case configv1.GCPPlatformType:
+		if failureDomains.GCP == nil {
+			return nil
+		}
+
+		for i, fd := range *failureDomains.GCP {
+			if fd.Zone == "" {
+				errs = append(errs, field.Required(failureDomainsPath.Child("gcp").Index(i).Child("zone"), "a zone must be specified"))
+			}
+		}
+	case configv1.OpenStackPlatformType:
+		if failureDomains.OpenStack == nil {
+			return nil
+		}
+
+		for i, fd := range *failureDomains.OpenStack {
+			if fd.AvailabilityZone == "" {
+				errs = append(errs, field.Required(failureDomainsPath.Child("openstack").Index(i).Child("availabilityZone"), "an availability zone must be specified"))
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkZonelessAWSFailureDomains ensures that each AWS failure domain specifies at least one of
+// an availability zone or a subnet, when zoneless failure domains are allowed.
+// A failure domain with neither does not constrain where the machine is placed.
+func checkZonelessAWSFailureDomains(failureDomainsPath *field.Path, failureDomains machinev1.FailureDomains) []error {
+	if failureDomains.Platform != configv1.AWSPlatformType || failureDomains.AWS == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	for i, fd := range *failureDomains.AWS {
+		if fd.Placement.AvailabilityZone == "" && fd.Subnet == nil {
+			errs = append(errs, field.Required(failureDomainsPath.Child("aws").Index(i).Child("placement", "availabilityZone"), "an availability zone or a subnet must be specified"))
+		}
+	}
+
+	return errs
+}
+
+// checkFailureDomainSubnetReferences ensures that all AWS failure domains reference their subnet
+// using the same reference type, when RequireConsistentSubnetReferences is configured on the webhook.
+// Failure domains without a subnet are not considered.
+func (r *ControlPlaneMachineSetWebhook) checkFailureDomainSubnetReferences(cpms *machinev1.ControlPlaneMachineSet) []error {
+	failureDomainsPath := field.NewPath("spec", "template", "machines_v1beta1_machine_openshift_io", "failureDomains")
+
+	if !r.RequireConsistentSubnetReferences || cpms.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+		return nil
+	}
+
+	failureDomains := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains
+	if failureDomains.Platform != configv1.AWSPlatformType || failureDomains.AWS == nil {
+		return nil
+	}
+
+	var referenceType machinev1.AWSResourceReferenceType
+
+	for _, fd := range *failureDomains.AWS {
+		if fd.Subnet == nil {
+			continue
+		}
 
-		if !found {
-			missing = append(missing, outerItem)
+		if referenceType == "" {
+			referenceType = fd.Subnet.Type
+			continue
+		}
+
+		if fd.Subnet.Type != referenceType {
+			return []error{field.Forbidden(failureDomainsPath, "all failure domains must use the same subnet reference type")}
 		}
 	}
 
-	return missing
+	return nil
 }
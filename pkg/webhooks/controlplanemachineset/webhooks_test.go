@@ -18,19 +18,61 @@ package controlplanemachineset
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
 )
 
+// warningCollector records the admission warnings returned by the API server.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+// HandleWarningHeader implements rest.WarningHandler.
+func (w *warningCollector) HandleWarningHeader(_ int, _ string, text string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.warnings = append(w.warnings, text)
+}
+
+// Warnings returns the warnings recorded so far.
+func (w *warningCollector) Warnings() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string{}, w.warnings...)
+}
+
+// newWarningClient returns a client for the test environment, along with a collector for the
+// admission warnings returned to that client.
+func newWarningClient() (*warningCollector, client.Client) {
+	warnings := &warningCollector{}
+	warningConfig := rest.CopyConfig(cfg)
+	warningConfig.WarningHandler = warnings
+
+	warningClient, err := client.New(warningConfig, client.Options{Scheme: testScheme})
+	Expect(err).ToNot(HaveOccurred())
+
+	return warnings, warningClient
+}
+
 // stringPtr returns a pointer to the string value.
 func stringPtr(s string) *string {
 	return &s
@@ -41,13 +83,17 @@ var _ = Describe("Webhooks", func() {
 	var mgrDone chan struct{}
 
 	var namespaceName string
+	var infrastructure *configv1.Infrastructure
 
-	BeforeEach(func() {
-		By("Setting up a namespace for the test")
-		ns := resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-").Build()
-		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
-		namespaceName = ns.GetName()
+	var filterSubnet = machinev1.AWSResourceReference{
+		Type: machinev1.AWSFiltersReferenceType,
+		Filters: &[]machinev1.AWSResourceFilter{{
+			Name:   "tag:Name",
+			Values: []string{"aws-subnet-12345678"},
+		}},
+	}
 
+	startManager := func(wh *ControlPlaneMachineSetWebhook) {
 		By("Setting up a manager and webhook")
 		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 			Scheme:             testScheme,
@@ -58,7 +104,6 @@ var _ = Describe("Webhooks", func() {
 		})
 		Expect(err).ToNot(HaveOccurred(), "Manager should be able to be created")
 
-		wh := &ControlPlaneMachineSetWebhook{}
 		Expect(wh.SetupWebhookWithManager(mgr)).To(Succeed(), "Webhook should be able to register with manager")
 
 		By("Starting the manager")
@@ -72,18 +117,41 @@ var _ = Describe("Webhooks", func() {
 
 			Expect(mgr.Start(mgrCtx)).To(Succeed())
 		}()
-	})
+	}
 
-	AfterEach(func() {
+	stopManager := func() {
 		By("Stopping the manager")
 		mgrCancel()
 		// Wait for the mgrDone to be closed, which will happen once the mgr has stopped
 		<-mgrDone
+	}
+
+	BeforeEach(func() {
+		By("Setting up the cluster infrastructure")
+		infrastructure = resourcebuilder.Infrastructure().Build()
+		infrastructureStatus := infrastructure.Status.DeepCopy()
+		Expect(k8sClient.Create(ctx, infrastructure)).To(Succeed())
+
+		infrastructure.Status = *infrastructureStatus
+		Expect(k8sClient.Status().Update(ctx, infrastructure)).To(Succeed())
+
+		By("Setting up a namespace for the test")
+		ns := resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		startManager(&ControlPlaneMachineSetWebhook{Namespace: namespaceName})
+	})
+
+	AfterEach(func() {
+		stopManager()
 
 		test.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
 			&machinev1beta1.Machine{},
 			&machinev1.ControlPlaneMachineSet{},
 		)
+
+		Expect(k8sClient.Delete(ctx, infrastructure)).To(Succeed())
 	})
 
 	Context("on create", func() {
@@ -92,16 +160,15 @@ var _ = Describe("Webhooks", func() {
 
 		Context("when validating without failure domains", func() {
 			BeforeEach(func() {
-				providerSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1")
+				providerSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")
 				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
 				// Default CPMS builder should be valid, individual tests will override to make it invalid
 				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate)
 
-				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName)
-				controlPlaneMachineBuilder := machineBuilder.WithGenerateName("control-plane-machine-").AsMaster().WithProviderSpecBuilder(providerSpec)
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
 				By("Creating a selection of Machines")
-				for i := 0; i < 3; i++ {
-					controlPlaneMachine := controlPlaneMachineBuilder.Build()
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder, providerSpec) {
 					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
 				}
 			})
@@ -111,11 +178,75 @@ var _ = Describe("Webhooks", func() {
 				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
 			})
 
+			It("with control plane machines outside of the selector", func() {
+				By("Creating a control plane Machine belonging to a different cluster")
+				otherMachine := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("other-control-plane-machine-").AsMaster().
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "other-cluster-id").
+					WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build()
+				Expect(k8sClient.Create(ctx, otherMachine)).To(Succeed())
+
+				By("Creating a worker Machine within the same cluster")
+				worker := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("worker-machine-").AsWorker().
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id").
+					WithProviderSpecBuilder(resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")).Build()
+				Expect(k8sClient.Create(ctx, worker)).To(Succeed())
+
+				// Only the 3 selected control plane machines should be considered for the replica count.
+				cpms := builder.Build()
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with a populated status", func() {
+				cpms := builder.Build()
+				cpms.Status = machinev1.ControlPlaneMachineSetStatus{
+					ObservedGeneration: 2,
+					Replicas:           3,
+				}
+
+				By("Validating the ControlPlaneMachineSet directly, as the API server drops the status on create")
+				webhook := &ControlPlaneMachineSetWebhook{Namespace: namespaceName, client: k8sClient}
+				Expect(webhook.ValidateCreate(ctx, cpms)).To(MatchError("status: Forbidden: status must not be set on create"))
+			})
+
 			It("with a disallowed name", func() {
 				cpms := builder.WithName("disallowed").Build()
 				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("name: Invalid value: \"disallowed\": control plane machine set name must be cluster"))
 			})
 
+			It("with a namespace other than the operator namespace", func() {
+				By("Setting up a second namespace")
+				otherNamespace := resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-other-").Build()
+				Expect(k8sClient.Create(ctx, otherNamespace)).To(Succeed())
+
+				cpms := builder.WithNamespace(otherNamespace.GetName()).Build()
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"metadata.namespace: Invalid value: \"%s\": control plane machine set must be created in the %s namespace", otherNamespace.GetName(), namespaceName,
+				)))
+			})
+
+			It("with a webhook that has not been set up with a manager", func() {
+				cpms := builder.Build()
+
+				By("Validating the ControlPlaneMachineSet directly, so that the namespace is not defaulted by the setup")
+				webhook := &ControlPlaneMachineSetWebhook{client: k8sClient}
+				Expect(webhook.ValidateCreate(ctx, cpms)).To(MatchError(ContainSubstring(
+					"metadata.namespace: Invalid value: \"%s\": control plane machine set must be created in the openshift-machine-api namespace", namespaceName,
+				)))
+			})
+
+			It("with a disallowed name in a namespace other than the operator namespace", func() {
+				By("Setting up a second namespace")
+				otherNamespace := resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-other-").Build()
+				Expect(k8sClient.Create(ctx, otherNamespace)).To(Succeed())
+
+				cpms := builder.WithName("disallowed").WithNamespace(otherNamespace.GetName()).Build()
+				err := k8sClient.Create(ctx, cpms)
+				Expect(err).To(MatchError(ContainSubstring(
+					"metadata.namespace: Invalid value: \"%s\": control plane machine set must be created in the %s namespace", otherNamespace.GetName(), namespaceName,
+				)))
+				Expect(err).ToNot(MatchError(ContainSubstring("control plane machine set name must be cluster")))
+			})
+
 			It("with 4 replicas", func() {
 				// This is an openapi validation but it makes sense to include it here as well
 				cpms := builder.WithReplicas(4).Build()
@@ -127,17 +258,89 @@ var _ = Describe("Webhooks", func() {
 					MatchLabels: map[string]string{
 						openshiftMachineRoleLabel:            masterMachineRole,
 						openshiftMachineTypeLabel:            masterMachineRole,
-						machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
+						machinev1beta1.MachineClusterIDLabel: "different-id",
 					},
 				}).WithMachineTemplateBuilder(
 					machineTemplate.WithLabels(map[string]string{
 						openshiftMachineRoleLabel:            masterMachineRole,
 						openshiftMachineTypeLabel:            masterMachineRole,
-						machinev1beta1.MachineClusterIDLabel: "different-id",
+						machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
 					}),
 				).Build()
 
-				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Invalid value: map[string]string{\"machine.openshift.io/cluster-api-cluster\":\"different-id\", \"machine.openshift.io/cluster-api-machine-role\":\"master\", \"machine.openshift.io/cluster-api-machine-type\":\"master\"}: selector does not match template labels"))
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Invalid value: map[string]string{\"machine.openshift.io/cluster-api-cluster\":\"cpms-cluster-test-id\", \"machine.openshift.io/cluster-api-machine-role\":\"master\", \"machine.openshift.io/cluster-api-machine-type\":\"master\"}: selector does not match template labels"))
+			})
+
+			It("with a selector that cannot be parsed", func() {
+				cpms := builder.WithSelector(metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						openshiftMachineRoleLabel:            masterMachineRole,
+						openshiftMachineTypeLabel:            masterMachineRole,
+						machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
+						"invalid label key!":                 "value",
+					},
+				}).Build()
+
+				err := k8sClient.Create(ctx, cpms)
+				Expect(err).To(MatchError(ContainSubstring("spec.selector: Invalid value")))
+				Expect(err).To(MatchError(ContainSubstring("could not convert label selector to selector")))
+				Expect(err).ToNot(MatchError(ContainSubstring("spec.replicas")))
+			})
+
+			It("with a selector that matches worker machines", func() {
+				clusterLabels := map[string]string{
+					machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
+				}
+
+				By("Creating a worker Machine with labels overlapping the selector")
+				worker := resourcebuilder.Machine().WithNamespace(namespaceName).WithName("worker-machine-xyz").
+					WithLabels(map[string]string{
+						openshiftMachineRoleLabel:            "worker",
+						openshiftMachineTypeLabel:            "worker",
+						machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
+					}).Build()
+				Expect(k8sClient.Create(ctx, worker)).To(Succeed())
+
+				cpms := builder.WithSelector(metav1.LabelSelector{
+					MatchLabels: clusterLabels,
+				}).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.selector: Forbidden: selector matches non-control-plane machines [worker-machine-xyz]"))
+			})
+
+			It("with a selector that uses matchExpressions", func() {
+				cpms := builder.WithSelector(metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						openshiftMachineRoleLabel:            masterMachineRole,
+						openshiftMachineTypeLabel:            masterMachineRole,
+						machinev1beta1.MachineClusterIDLabel: "cpms-cluster-test-id",
+					},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      openshiftMachineRoleLabel,
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   []string{masterMachineRole},
+						},
+					},
+				}).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.selector: Forbidden: matchExpressions are not supported, use matchLabels"))
+			})
+
+			It("with a cluster ID label that does not match the infrastructure name", func() {
+				clusterLabels := map[string]string{
+					openshiftMachineRoleLabel:            masterMachineRole,
+					openshiftMachineTypeLabel:            masterMachineRole,
+					machinev1beta1.MachineClusterIDLabel: "wrong-id",
+				}
+
+				cpms := builder.WithSelector(metav1.LabelSelector{
+					MatchLabels: clusterLabels,
+				}).WithMachineTemplateBuilder(
+					machineTemplate.WithLabels(clusterLabels),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("[spec.replicas: Forbidden: control plane machine set replicas (3) does not match the current number of control plane machines (0), spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Invalid value: \"wrong-id\": must match cluster infrastructure name \"cpms-cluster-test-id\"]"))
 			})
 
 			It("with no cluster ID label is set", func() {
@@ -188,6 +391,61 @@ var _ = Describe("Webhooks", func() {
 				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Required value: machine.openshift.io/cluster-api-machine-type label is required"))
 			})
 
+			It("with a machine set label on the template", func() {
+				cpms := builder.WithMachineTemplateBuilder(
+					machineTemplate.WithLabel(openshiftMachineSetLabel, "cluster-worker-us-east-1a"),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Forbidden: label machine.openshift.io/cluster-api-machineset is not allowed on control plane machines"))
+			})
+
+			It("with a machine deployment label on the template", func() {
+				cpms := builder.WithMachineTemplateBuilder(
+					machineTemplate.WithLabel(openshiftMachineDeploymentLabel, "cluster-worker"),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Forbidden: label machine.openshift.io/cluster-api-machine-deployment is not allowed on control plane machines"))
+			})
+
+			It("with no AMI in the provider spec", func() {
+				cpms := builder.WithMachineTemplateBuilder(
+					machineTemplate.WithProviderSpecBuilder(
+						resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithAMI(machinev1beta1.AWSResourceReference{}),
+					),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.ami: Required value: a boot image must be specified"))
+			})
+
+			It("with a root volume below the minimum size in the provider spec", func() {
+				cpms := builder.WithMachineTemplateBuilder(
+					machineTemplate.WithProviderSpecBuilder(
+						resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithRootVolumeSize(4),
+					),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.blockDevices[0].ebs.volumeSize: Invalid value: 4: root volume size 4 is below the minimum of 8"))
+			})
+
+			It("with an empty user data secret name in the provider spec", func() {
+				cpms := builder.WithMachineTemplateBuilder(
+					machineTemplate.WithProviderSpecBuilder(
+						resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithUserDataSecret(""),
+					),
+				).Build()
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.userDataSecret: Required value: a user data secret must be specified"))
+			})
+
+			It("with a provider spec that cannot be decoded", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{
+					Raw: []byte(`{"kind":"AWSMachineProviderConfig","instanceType":12345}`),
+				}
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec: Invalid value: \"could not unmarshal provider spec: json: cannot unmarshal number into Go struct field AWSMachineProviderConfig.instanceType of type string\": provider spec could not be decoded"))
+			})
+
 			It("with no machine template", func() {
 				cpms := builder.WithMachineTemplateBuilder(nil).Build()
 
@@ -205,13 +463,6 @@ var _ = Describe("Webhooks", func() {
 
 		Context("when validating failure domains on AWS", func() {
 			var builder resourcebuilder.ControlPlaneMachineSetBuilder
-			var filterSubnet = machinev1.AWSResourceReference{
-				Type: machinev1.AWSFiltersReferenceType,
-				Filters: &[]machinev1.AWSResourceFilter{{
-					Name:   "tag:Name",
-					Values: []string{"aws-subnet-12345678"},
-				}},
-			}
 
 			var filterSubnetDifferent = machinev1.AWSResourceReference{
 				Type: machinev1.AWSFiltersReferenceType,
@@ -236,15 +487,11 @@ var _ = Describe("Webhooks", func() {
 			var usEast1fBuilder = resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1f").WithSubnet(filterSubnet)
 
 			BeforeEach(func() {
-				By("Setting up a namespace for the test")
-				ns := resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-").Build()
-				Expect(k8sClient.Create(ctx, ns)).To(Succeed())
-				namespaceName = ns.GetName()
-
 				providerSpec := resourcebuilder.AWSProviderSpec()
 				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
 				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName)
-				controlPlaneMachineBuilder := machineBuilder.WithGenerateName("control-plane-machine-").AsMaster()
+				controlPlaneMachineBuilder := machineBuilder.WithGenerateName("control-plane-machine-").AsMaster().
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
 				workerMachineBuilder := machineBuilder.WithGenerateName("worker-machine-").AsWorker()
 				machineTemplate := resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
 
@@ -286,6 +533,59 @@ var _ = Describe("Webhooks", func() {
 				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
 			})
 
+			It("with a failure domain without an availability zone", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						usEast1aBuilder,
+						usEast1bBuilder,
+						resourcebuilder.AWSFailureDomain().WithSubnet(filterSubnet),
+					),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[2].placement.availabilityZone: Required value: an availability zone must be specified when a subnet is specified")))
+			})
+
+			It("with failure domains from a different platform", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						usEast1aBuilder,
+						usEast1bBuilder,
+						usEast1cBuilder,
+					),
+				)).Build()
+
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains.Azure = &[]machinev1.AzureFailureDomain{
+					resourcebuilder.AzureFailureDomain().WithZone("1").Build(),
+				}
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.azure[0]: Invalid value: \"AzureFailureDomain{Zone:1}\": failure domain platform Azure does not match failure domains platform AWS"))
+			})
+
+			It("with a failure domains platform that does not match the provider spec", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AzureFailureDomains(),
+				)).Build()
+
+				err := k8sClient.Create(ctx, cpms)
+				Expect(err).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.platform: Invalid value: \"Azure\": failure domains platform must match provider spec platform AWS")))
+				Expect(err).ToNot(MatchError(ContainSubstring("failureDomains.azure[0]")))
+			})
+
+			It("with AWS failure domains and a failure domains platform that does not match the provider spec", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						usEast1aBuilder,
+						usEast1bBuilder,
+						usEast1cBuilder,
+					),
+				).WithPlatformType(configv1.AzurePlatformType)).Build()
+
+				Expect(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains.Platform).To(Equal(configv1.AzurePlatformType))
+				err := k8sClient.Create(ctx, cpms)
+				Expect(err).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.platform: Invalid value: \"Azure\": failure domains platform must match provider spec platform AWS")))
+				Expect(err).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[0]: Invalid value: \"%s\": failure domain platform AWS does not match failure domains platform Azure", failuredomain.NewAWSFailureDomain(usEast1aBuilder.Build()))))
+			})
+
 			It("with a invalid subnet filter - different value", func() {
 				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
 					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
@@ -340,6 +640,35 @@ var _ = Describe("Webhooks", func() {
 				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Forbidden: no control plane machine is using specified failure domain(s) [AWSFailureDomain{AvailabilityZone:us-east-1d, Subnet:{Type:filters, Value:&[{Name:tag:Name Values:[aws-subnet-12345678]}]}}]"))
 			})
 
+			Context("when collecting warnings", func() {
+				var warnings *warningCollector
+				var warningClient client.Client
+
+				BeforeEach(func() {
+					warnings, warningClient = newWarningClient()
+				})
+
+				It("with a provider spec zone and failure domains", func() {
+					cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+						resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+							usEast1aBuilder,
+							usEast1bBuilder,
+							usEast1cBuilder,
+						),
+					)).Build()
+
+					Expect(warningClient.Create(ctx, cpms)).To(Succeed())
+					Expect(warnings.Warnings()).To(ConsistOf("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec: the provider spec zone us-east-1a will be overridden by the failure domains"))
+				})
+
+				It("with a provider spec zone and no failure domains", func() {
+					cpms := builder.Build()
+
+					Expect(warningClient.Create(ctx, cpms)).To(Succeed())
+					Expect(warnings.Warnings()).To(BeEmpty())
+				})
+			})
+
 			It("when the availability zones don't match", func() {
 				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
 					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
@@ -359,6 +688,337 @@ var _ = Describe("Webhooks", func() {
 				Expect(err).To(MatchError(ContainSubstring("AWSFailureDomain{AvailabilityZone:us-east-1e, Subnet:{Type:filters, Value:&[{Name:tag:Name Values:[aws-subnet-12345678]}]}}")))
 				Expect(err).To(MatchError(ContainSubstring("AWSFailureDomain{AvailabilityZone:us-east-1f, Subnet:{Type:filters, Value:&[{Name:tag:Name Values:[aws-subnet-12345678]}]}}")))
 			})
+
+			It("when the platform is set with an empty failure domain list", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Required value: at least one failure domain must be specified when platform is set")))
+			})
+		})
+
+		Context("when validating failure domains on AWS with 5 replicas", func() {
+			var builder resourcebuilder.ControlPlaneMachineSetBuilder
+			var machineTemplate resourcebuilder.OpenShiftMachineV1Beta1TemplateBuilder
+
+			zones := []string{"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d", "us-east-1e"}
+
+			BeforeEach(func() {
+				providerSpec := resourcebuilder.AWSProviderSpec()
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithReplicas(5)
+
+				providerSpecBuilders := []resourcebuilder.RawExtensionBuilder{}
+				for _, az := range zones {
+					providerSpecBuilders = append(providerSpecBuilders, providerSpec.WithAvailabilityZone(az))
+				}
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(5, machineBuilder, providerSpecBuilders...) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+			})
+
+			It("with a valid failure domains spec spread across 5 failure domains", func() {
+				failureDomainBuilders := []resourcebuilder.AWSFailureDomainBuilder{}
+				for _, az := range zones {
+					failureDomainBuilders = append(failureDomainBuilders, resourcebuilder.AWSFailureDomain().WithAvailabilityZone(az).WithSubnet(filterSubnet))
+				}
+
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(failureDomainBuilders...),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+		})
+
+		Context("when validating the number of failure domains on AWS with 5 replicas", func() {
+			var providerSpec resourcebuilder.AWSProviderSpecBuilder
+
+			// buildControlPlaneMachineSet creates 5 control plane machines spread across the zones
+			// provided and returns a 5 replica control plane machine set with matching failure domains.
+			buildControlPlaneMachineSet := func(zones []string) *machinev1.ControlPlaneMachineSet {
+				providerSpecBuilders := []resourcebuilder.RawExtensionBuilder{}
+				failureDomainBuilders := []resourcebuilder.AWSFailureDomainBuilder{}
+
+				for _, az := range zones {
+					providerSpecBuilders = append(providerSpecBuilders, providerSpec.WithAvailabilityZone(az))
+					failureDomainBuilders = append(failureDomainBuilders, resourcebuilder.AWSFailureDomain().WithAvailabilityZone(az).WithSubnet(filterSubnet))
+				}
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(5, machineBuilder, providerSpecBuilders...) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+
+				machineTemplate := resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec).WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(failureDomainBuilders...),
+				)
+
+				return resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithReplicas(5).WithMachineTemplateBuilder(machineTemplate).Build()
+			}
+
+			BeforeEach(func() {
+				providerSpec = resourcebuilder.AWSProviderSpec()
+			})
+
+			It("with 2 failure domains", func() {
+				cpms := buildControlPlaneMachineSet([]string{"us-east-1a", "us-east-1b"})
+
+				Expect(apierrors.ReasonForError(k8sClient.Create(ctx, cpms))).To(BeEquivalentTo("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Forbidden: at least 3 failure domains are required for 5 control plane machines"))
+			})
+
+			It("with 3 failure domains", func() {
+				cpms := buildControlPlaneMachineSet([]string{"us-east-1a", "us-east-1b", "us-east-1c"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+		})
+
+		Context("when running in report only mode", func() {
+			var warnings *warningCollector
+			var warningClient client.Client
+
+			BeforeEach(func() {
+				By("Restarting the manager with a report only webhook")
+				stopManager()
+				startManager(&ControlPlaneMachineSetWebhook{Namespace: namespaceName, ReportOnly: true})
+
+				warnings, warningClient = newWarningClient()
+
+				providerSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a")
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate)
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder, providerSpec) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+			})
+
+			It("with a valid spec", func() {
+				cpms := builder.Build()
+				Expect(warningClient.Create(ctx, cpms)).To(Succeed())
+				Expect(warnings.Warnings()).To(BeEmpty())
+			})
+
+			It("with a mismatched selector", func() {
+				cpms := builder.WithSelector(metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						openshiftMachineRoleLabel:            masterMachineRole,
+						openshiftMachineTypeLabel:            masterMachineRole,
+						machinev1beta1.MachineClusterIDLabel: "different-id",
+					},
+				}).Build()
+
+				Expect(warningClient.Create(ctx, cpms)).To(Succeed())
+				Expect(warnings.Warnings()).To(ConsistOf(ContainSubstring("selector does not match template labels")))
+			})
+		})
+
+		Context("when warning on mixed instance types", func() {
+			var warnings *warningCollector
+			var warningClient client.Client
+
+			BeforeEach(func() {
+				By("Restarting the manager with mixed instance type warnings")
+				stopManager()
+				startManager(&ControlPlaneMachineSetWebhook{Namespace: namespaceName, WarnOnMixedInstanceTypes: true})
+
+				warnings, warningClient = newWarningClient()
+
+				providerSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithInstanceType("m5.xlarge")
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate)
+			})
+
+			createMachines := func(instanceTypes ...string) {
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).AsMaster().
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+
+				By("Creating a selection of Machines")
+				for i, instanceType := range instanceTypes {
+					providerSpec := resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithInstanceType(instanceType)
+					machine := machineBuilder.WithName(fmt.Sprintf("master-%d", i)).WithProviderSpecBuilder(providerSpec).Build()
+					Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+				}
+			}
+
+			It("with machines using the same instance type", func() {
+				createMachines("m5.xlarge", "m5.xlarge", "m5.xlarge")
+
+				Expect(warningClient.Create(ctx, builder.Build())).To(Succeed())
+				Expect(warnings.Warnings()).To(BeEmpty())
+			})
+
+			It("with machines using different instance types", func() {
+				createMachines("m5.xlarge", "m5.xlarge", "m5.2xlarge")
+
+				Expect(warningClient.Create(ctx, builder.Build())).To(Succeed())
+				Expect(warnings.Warnings()).To(ConsistOf("control plane machines use different instance types: m5.2xlarge [master-2], m5.xlarge [master-0 master-1]"))
+			})
+		})
+
+		Context("when warning on failure domains without workers", func() {
+			var warnings *warningCollector
+			var warningClient client.Client
+
+			BeforeEach(func() {
+				By("Restarting the manager with failure domain worker warnings")
+				stopManager()
+				startManager(&ControlPlaneMachineSetWebhook{Namespace: namespaceName, WarnOnFailureDomainsWithoutWorkers: true})
+
+				warnings, warningClient = newWarningClient()
+
+				providerSpec := resourcebuilder.AWSProviderSpec()
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName)
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder,
+					providerSpec.WithAvailabilityZone("us-east-1a"),
+					providerSpec.WithAvailabilityZone("us-east-1b"),
+					providerSpec.WithAvailabilityZone("us-east-1f"),
+				) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+
+				By("Creating worker Machines in only some of the availability zones")
+				workerBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("worker-machine-").AsWorker()
+				for _, zone := range []string{"us-east-1a", "us-east-1b"} {
+					worker := workerBuilder.WithProviderSpecBuilder(providerSpec.WithAvailabilityZone(zone)).Build()
+					Expect(k8sClient.Create(ctx, worker)).To(Succeed())
+				}
+			})
+
+			It("with a failure domain in an availability zone without workers", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1f").WithSubnet(filterSubnet),
+					),
+				)).Build()
+
+				Expect(warningClient.Create(ctx, cpms)).To(Succeed())
+				Expect(warnings.Warnings()).To(ContainElement("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[2].placement.availabilityZone: no worker machines use the availability zone us-east-1f"))
+				Expect(warnings.Warnings()).ToNot(ContainElement(ContainSubstring("no worker machines use the availability zone us-east-1a")))
+				Expect(warnings.Warnings()).ToNot(ContainElement(ContainSubstring("no worker machines use the availability zone us-east-1b")))
+			})
+		})
+
+		Context("when requiring consistent subnet references", func() {
+			var idSubnet = machinev1.AWSResourceReference{
+				Type: machinev1.AWSIDReferenceType,
+				ID:   stringPtr("subnet-us-east-1c"),
+			}
+
+			BeforeEach(func() {
+				By("Restarting the manager with consistent subnet references required")
+				stopManager()
+				startManager(&ControlPlaneMachineSetWebhook{Namespace: namespaceName, RequireConsistentSubnetReferences: true})
+
+				providerSpec := resourcebuilder.AWSProviderSpec()
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName)
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder,
+					providerSpec.WithAvailabilityZone("us-east-1a"),
+					providerSpec.WithAvailabilityZone("us-east-1b"),
+					providerSpec.WithAvailabilityZone("us-east-1c"),
+				) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+			})
+
+			It("with failure domains using the same subnet reference type", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(filterSubnet),
+					),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with failure domains mixing id and filter subnets", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(idSubnet),
+					),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Forbidden: all failure domains must use the same subnet reference type")))
+			})
+		})
+
+		Context("when allowing zoneless AWS failure domains", func() {
+			BeforeEach(func() {
+				By("Restarting the manager with zoneless AWS failure domains allowed")
+				stopManager()
+				startManager(&ControlPlaneMachineSetWebhook{
+					Namespace:                   namespaceName,
+					AllowZonelessFailureDomains: map[configv1.PlatformType]bool{configv1.AWSPlatformType: true},
+				})
+
+				providerSpec := resourcebuilder.AWSProviderSpec()
+				machineTemplate = resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				builder = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName)
+
+				machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+					WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+				By("Creating a selection of Machines")
+				for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder,
+					providerSpec.WithAvailabilityZone("us-east-1a"),
+					providerSpec.WithAvailabilityZone("us-east-1b"),
+					providerSpec.WithAvailabilityZone(""),
+				) {
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+			})
+
+			It("with a failure domain with a subnet but no availability zone", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithSubnet(filterSubnet),
+					),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with a failure domain with neither a subnet nor an availability zone", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet),
+						resourcebuilder.AWSFailureDomain(),
+					),
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[2].placement.availabilityZone: Required value: an availability zone or a subnet must be specified")))
+			})
 		})
 	})
 
@@ -371,11 +1031,10 @@ var _ = Describe("Webhooks", func() {
 			// Default CPMS builder should be valid
 			cpms = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate).Build()
 
-			machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName)
-			controlPlaneMachineBuilder := machineBuilder.WithGenerateName("control-plane-machine-").AsMaster().WithProviderSpecBuilder(providerSpec)
+			machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("control-plane-machine-").
+				WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
 			By("Creating a selection of Machines")
-			for i := 0; i < 3; i++ {
-				controlPlaneMachine := controlPlaneMachineBuilder.Build()
+			for _, controlPlaneMachine := range resourcebuilder.ControlPlaneMachines(3, machineBuilder, providerSpec) {
 				Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
 			}
 
@@ -392,6 +1051,15 @@ var _ = Describe("Webhooks", func() {
 			})).Should(Succeed())
 		})
 
+		It("with an update to the providerSpec platform type", func() {
+			// Change the providerSpec to a different platform, expect the update to be rejected
+			rawProviderSpec := resourcebuilder.AzureProviderSpec().BuildRawExtension()
+
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = rawProviderSpec
+			})).Should(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec: Forbidden: platform type cannot be changed from AWS to Azure")), "The provider spec platform type should be immutable")
+		})
+
 		It("with 4 replicas", func() {
 			// This is an openapi validation but it makes sense to include it here as well
 			Eventually(komega.Update(cpms, func() {
@@ -405,7 +1073,7 @@ var _ = Describe("Webhooks", func() {
 			Eventually(komega.Update(cpms, func() {
 				five := int32(5)
 				cpms.Spec.Replicas = &five
-			})).Should(MatchError(ContainSubstring(`spec.replicas: Forbidden: control plane machine set replicas cannot be changed`)), "Replicas should be immutable")
+			})).Should(MatchError(ContainSubstring(`spec.replicas: Forbidden: control plane machine set replicas cannot be changed from 3 to 5`)), "Replicas should be immutable")
 		})
 
 		It("when modifying the machine labels and the selector still matches", func() {
@@ -437,4 +1105,71 @@ var _ = Describe("Webhooks", func() {
 			})).Should(MatchError(ContainSubstring("Forbidden: control plane machine set selector is immutable")), "The selector should be immutable")
 		})
 	})
+
+	Context("on update with failure domains", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		usEast1aBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(filterSubnet)
+		usEast1bBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(filterSubnet)
+		usEast1cBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(filterSubnet)
+
+		BeforeEach(func() {
+			providerSpec := resourcebuilder.AWSProviderSpec()
+			machineTemplate := resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec).WithFailureDomainsBuilder(
+				resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(usEast1aBuilder, usEast1bBuilder, usEast1cBuilder),
+			)
+			cpms = resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate).Build()
+
+			machineBuilder := resourcebuilder.Machine().WithNamespace(namespaceName).AsMaster().
+				WithLabel(machinev1beta1.MachineClusterIDLabel, "cpms-cluster-test-id")
+
+			By("Creating a selection of Machines")
+			for i, az := range []string{"us-east-1a", "us-east-1b", "us-east-1c"} {
+				machine := machineBuilder.WithName(fmt.Sprintf("master-%d", i)).WithProviderSpecBuilder(providerSpec.WithAvailabilityZone(az)).Build()
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+			}
+
+			By("Creating a valid ControlPlaneMachineSet")
+			Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+		})
+
+		It("when removing a failure domain that is in use", func() {
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains = resourcebuilder.AWSFailureDomains().
+					WithFailureDomainBuilders(usEast1aBuilder, usEast1bBuilder).BuildFailureDomains()
+			})).Should(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Forbidden: removing failure domain us-east-1c would orphan machines [master-2]")))
+		})
+
+		It("when removing a failure domain that only specifies an availability zone and is in use", func() {
+			usEast1aZoneOnlyBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a")
+			usEast1bZoneOnlyBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b")
+			usEast1cZoneOnlyBuilder := resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c")
+
+			By("Replacing the failure domains with failure domains that do not specify a subnet")
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains = resourcebuilder.AWSFailureDomains().
+					WithFailureDomainBuilders(usEast1aZoneOnlyBuilder, usEast1bZoneOnlyBuilder, usEast1cZoneOnlyBuilder).BuildFailureDomains()
+			})).Should(Succeed(), "The machines still match the replacement failure domains")
+
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains = resourcebuilder.AWSFailureDomains().
+					WithFailureDomainBuilders(usEast1aZoneOnlyBuilder, usEast1bZoneOnlyBuilder).BuildFailureDomains()
+			})).Should(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains: Forbidden: removing failure domain us-east-1c would orphan machines [master-2]")))
+		})
+
+		It("when adding a failure domain", func() {
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains = resourcebuilder.AWSFailureDomains().
+					WithFailureDomainBuilders(usEast1aBuilder, usEast1bBuilder, usEast1cBuilder,
+						resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1d").WithSubnet(filterSubnet),
+					).BuildFailureDomains()
+			})).Should(Succeed())
+		})
+
+		It("when changing the failure domains platform", func() {
+			Eventually(komega.Update(cpms, func() {
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.FailureDomains = resourcebuilder.GCPFailureDomains().BuildFailureDomains()
+			})).Should(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.platform: Forbidden: failure domains platform is immutable")), "The failure domains platform should be immutable")
+		})
+	})
 })